@@ -0,0 +1,151 @@
+package main
+
+//go:generate go run . algorithms -o ../../../frontend/src/algorithms.json
+
+import (
+	"CryptographyCW/pkg/crypto"
+	"CryptographyCW/pkg/crypto/kat"
+	"CryptographyCW/pkg/crypto/rsa"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: cryptotool <command> [flags]
+
+Commands:
+  algorithms  print the algorithms, modes and paddings as GET /algorithms does
+  selftest    run the known-answer tests and a round trip through every mode
+  wiener      recover a small RSA private exponent from (e, N)
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "algorithms":
+		err = runAlgorithms(os.Args[2:])
+	case "selftest":
+		err = runSelfTest(os.Args[2:])
+	case "wiener":
+		err = runWiener(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// parseInt reads a decimal integer, or a hexadecimal one with a 0x prefix
+func parseInt(name, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("-%s is required", name)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid -%s %q", name, s)
+	}
+	return n, nil
+}
+
+func runWiener(args []string) error {
+	fs := flag.NewFlagSet("wiener", flag.ExitOnError)
+	eFlag := fs.String("e", "", "public exponent (decimal or 0x hex)")
+	nFlag := fs.String("n", "", "modulus (decimal or 0x hex)")
+	quiet := fs.Bool("q", false, "do not list the convergents tried")
+	fs.Parse(args)
+
+	e, err := parseInt("e", *eFlag)
+	if err != nil {
+		return err
+	}
+	n, err := parseInt("n", *nFlag)
+	if err != nil {
+		return err
+	}
+
+	result, err := rsa.WienerAttack(e, n)
+	if result != nil && !*quiet {
+		fmt.Println("Convergents k/d of e/N:")
+		for i, c := range result.Convergents {
+			fmt.Printf("  %3d  %s/%s\n", i, c.K, c.D)
+		}
+	}
+	if errors.Is(err, rsa.ErrAttackFailed) {
+		return fmt.Errorf("no convergent recovered d after %d tries", len(result.Convergents))
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("d = %s\np = %s\nq = %s\n", result.D, result.P, result.Q)
+	return nil
+}
+
+// runAlgorithms writes the catalog that GET /algorithms serves, which the
+// frontend bundles as its fallback
+func runAlgorithms(args []string) error {
+	fs := flag.NewFlagSet("algorithms", flag.ExitOnError)
+	out := fs.String("o", "", "file to write instead of standard output")
+	fs.Parse(args)
+
+	data, err := json.MarshalIndent(crypto.Supported(), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0o644)
+}
+
+func runSelfTest(args []string) error {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of vector files to use instead of the built-in ones")
+	verbose := fs.Bool("v", false, "list the algorithms without known-answer tests")
+	fs.Parse(args)
+
+	var vectors []kat.Vector
+	var err error
+	if *dir != "" {
+		vectors, err = kat.LoadDir(*dir)
+	} else {
+		vectors, err = kat.Builtin()
+	}
+	if err != nil {
+		return err
+	}
+
+	report := kat.SelfTest(vectors)
+	for _, failure := range report.Failures {
+		fmt.Println("FAIL", failure)
+	}
+	if *verbose && len(report.Untested) > 0 {
+		fmt.Println("No known answers for:", strings.Join(report.Untested, ", "))
+	}
+	fmt.Printf("%d known-answer tests, %d mode and padding round trips, %d failures\n",
+		report.Vectors, report.RoundTrips, len(report.Failures))
+
+	if !report.OK() {
+		return errors.New("self-test failed")
+	}
+	return nil
+}
@@ -1,16 +1,22 @@
+//go:build js && wasm
+
 package main
 
 import (
 	"CryptographyCW/pkg/crypto"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"slices"
 	"syscall/js"
+	"time"
 )
 
 func createResult(data interface{}, err error) map[string]interface{} {
 	if err != nil {
 		return map[string]interface{}{
 			"error": err.Error(),
+			"code":  errorCode(err),
 		}
 	}
 	return map[string]interface{}{
@@ -18,108 +24,276 @@ func createResult(data interface{}, err error) map[string]interface{} {
 	}
 }
 
-// Convert JS array to byte slice
-func jsArrayToBytes(arr js.Value) ([]byte, error) {
-	if arr.Type() != js.TypeObject {
-		return nil, fmt.Errorf("expected array, got %s", arr.Type().String())
+// errorCode classifies an error so callers can tell unsupported room
+// settings apart from bad input without parsing the message
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, crypto.ErrUnsupportedAlgorithm):
+		return "unsupported_algorithm"
+	case errors.Is(err, crypto.ErrUnsupportedMode):
+		return "unsupported_mode"
+	case errors.Is(err, crypto.ErrUnsupportedPadding):
+		return "unsupported_padding"
+	case errors.Is(err, crypto.ErrInvalidKeySize):
+		return "invalid_key_size"
+	case errors.Is(err, crypto.ErrInvalidBlockSize):
+		return "invalid_block_size"
+	case errors.Is(err, crypto.ErrInvalidIV):
+		return "invalid_iv"
+	case errors.Is(err, crypto.ErrInvalidPadding):
+		return "invalid_padding"
+	case errors.Is(err, crypto.ErrAuthenticationFailed):
+		return "authentication_failed"
+	default:
+		return "error"
+	}
+}
+
+// Read the mode and padding arguments at i and i+1, defaulting to CBC with
+// PKCS7 when they are omitted
+func jsModeAndPadding(args []js.Value, i int) (string, crypto.PaddingType, error) {
+	mode, padding := "CBC", crypto.PKCS7
+	if len(args) > i && args[i].Type() == js.TypeString {
+		mode = args[i].String()
 	}
+	if len(args) > i+1 && args[i+1].Type() == js.TypeString {
+		padding = crypto.PaddingType(args[i+1].String())
+	}
+
+	if !slices.Contains(crypto.Modes, mode) {
+		return "", "", fmt.Errorf("%w %q", crypto.ErrUnsupportedMode, mode)
+	}
+	if !slices.Contains(crypto.Paddings, padding) {
+		return "", "", fmt.Errorf("%w %q", crypto.ErrUnsupportedPadding, padding)
+	}
+	return mode, padding, nil
+}
 
-	length := arr.Length()
-	bytes := make([]byte, length)
-	for i := 0; i < length; i++ {
-		bytes[i] = byte(arr.Index(i).Int())
+// uint8Array is the JS Uint8Array constructor, the only type data crosses
+// the module boundary as
+var uint8Array = js.Global().Get("Uint8Array")
+
+// Copy a Uint8Array into a new byte slice
+func jsArrayToBytes(arr js.Value) ([]byte, error) {
+	if !arr.InstanceOf(uint8Array) {
+		return nil, fmt.Errorf("expected Uint8Array, got %s", arr.Type().String())
 	}
+
+	bytes := make([]byte, arr.Length())
+	js.CopyBytesToGo(bytes, arr)
 	return bytes, nil
 }
 
-// Convert byte slice to JS array
+// Copy a byte slice into a new Uint8Array
 func bytesToJSArray(bytes []byte) js.Value {
-	array := js.Global().Get("Uint8Array").New(len(bytes))
+	array := uint8Array.New(len(bytes))
 	js.CopyBytesToJS(array, bytes)
 	return array
 }
 
-func encrypt(this js.Value, args []js.Value) interface{} {
-	if len(args) < 4 {
-		return createResult(nil, fmt.Errorf("invalid number of arguments"))
+// jsCipherArgs holds the arguments shared by the encrypt and decrypt exports
+type jsCipherArgs struct {
+	algorithm string
+	key       []byte
+	data      []byte
+	iv        []byte
+	mode      string
+	padding   crypto.PaddingType
+}
+
+// Read (algorithm, key, data, iv, mode, padding), the first n >= 4 of which
+// are required
+func parseCipherArgs(args []js.Value, n int) (*jsCipherArgs, error) {
+	if len(args) < n {
+		return nil, fmt.Errorf("invalid number of arguments")
 	}
 
-	// Extract arguments
-	algorithm := args[0].String()
-	key := []byte(args[1].String())
+	// The key must be a derived key; a password string is never used as one
+	key, err := jsArrayToBytes(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
 
-	// Convert message to bytes
-	messageBytes, err := jsArrayToBytes(args[2])
+	data, err := jsArrayToBytes(args[2])
 	if err != nil {
-		return createResult(nil, fmt.Errorf("invalid message data: %v", err))
+		return nil, fmt.Errorf("invalid data: %v", err)
 	}
 
-	// Convert IV to bytes
 	iv, err := jsArrayToBytes(args[3])
 	if err != nil {
-		return createResult(nil, fmt.Errorf("invalid IV data: %v", err))
+		return nil, fmt.Errorf("invalid IV: %v", err)
 	}
 
-	// Create cipher
-	cipher, err := crypto.NewCipher(algorithm, key)
+	mode, padding, err := jsModeAndPadding(args, 4)
 	if err != nil {
-		return createResult(nil, fmt.Errorf("cipher creation failed: %v", err))
+		return nil, err
 	}
 
-	// Encrypt data
-	encrypted := cipher.EncryptCBC(messageBytes, iv)
-	if encrypted == nil {
-		return createResult(nil, fmt.Errorf("encryption failed"))
+	return &jsCipherArgs{
+		algorithm: args[0].String(),
+		key:       key,
+		data:      data,
+		iv:        iv,
+		mode:      mode,
+		padding:   padding,
+	}, nil
+}
+
+// encrypt(algorithm, key, plaintext, iv, mode, padding) encrypts a
+// Uint8Array with the room's mode and padding and returns the ciphertext
+// as a Uint8Array
+func encrypt(this js.Value, args []js.Value) interface{} {
+	a, err := parseCipherArgs(args, 4)
+	if err != nil {
+		return createResult(nil, err)
+	}
+
+	cipher, err := crypto.NewCipher(a.algorithm, a.key)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("cipher creation failed: %w", err))
+	}
+
+	encrypted, err := cipher.EncryptWithMode(a.data, a.iv, a.mode, a.padding)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("encryption failed: %w", err))
 	}
 
-	// Return base64 encoded string
-	return createResult(base64.StdEncoding.EncodeToString(encrypted), nil)
+	return createResult(bytesToJSArray(encrypted), nil)
 }
 
+// decrypt(algorithm, key, ciphertext, iv, mode, padding) reverses encrypt,
+// returning the plaintext bytes unchanged so binary data survives
 func decrypt(this js.Value, args []js.Value) interface{} {
-	if len(args) < 4 {
-		return createResult(nil, fmt.Errorf("invalid number of arguments"))
+	a, err := parseCipherArgs(args, 4)
+	if err != nil {
+		return createResult(nil, err)
+	}
+
+	cipher, err := crypto.NewCipher(a.algorithm, a.key)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("cipher creation failed: %w", err))
+	}
+
+	decrypted, err := cipher.DecryptWithMode(a.data, a.iv, a.mode, a.padding)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("decryption failed: %w", err))
+	}
+
+	return createResult(bytesToJSArray(decrypted), nil)
+}
+
+// Build the associated data from the from, sent_at and message_type arguments
+func jsAssociatedData(from, sentAt, msgType js.Value) ([]byte, error) {
+	t, err := time.Parse(time.RFC3339Nano, sentAt.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid sent_at: %v", err)
+	}
+	return crypto.AssociatedData(from.String(), t, msgType.String()), nil
+}
+
+// Create the encrypt-then-MAC scheme and associated data for sealMessage
+// and openMessage
+func jsAEAD(args []js.Value) (*crypto.EncryptThenMAC, *jsCipherArgs, []byte, error) {
+	a, err := parseCipherArgs(args, 9)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ad, err := jsAssociatedData(args[6], args[7], args[8])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	aead, err := crypto.NewAEAD(a.algorithm, a.key, a.mode, a.padding)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cipher creation failed: %w", err)
+	}
+	return aead, a, ad, nil
+}
+
+// sealMessage(algorithm, key, plaintext, iv, mode, padding, from, sentAt, messageType)
+// encrypts and authenticates a message for rooms that require AEAD
+func sealMessage(this js.Value, args []js.Value) interface{} {
+	aead, a, ad, err := jsAEAD(args)
+	if err != nil {
+		return createResult(nil, err)
+	}
+
+	sealed, err := aead.Seal(a.data, a.iv, ad)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("encryption failed: %w", err))
+	}
+
+	return createResult(bytesToJSArray(sealed), nil)
+}
+
+// openMessage(algorithm, key, sealed, iv, mode, padding, from, sentAt, messageType)
+// verifies and decrypts a message, rejecting anything that was tampered with
+func openMessage(this js.Value, args []js.Value) interface{} {
+	aead, a, ad, err := jsAEAD(args)
+	if err != nil {
+		return createResult(nil, err)
 	}
 
-	// Extract arguments
-	algorithm := args[0].String()
-	key := []byte(args[1].String())
+	opened, err := aead.Open(a.data, a.iv, ad)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("decryption failed: %w", err))
+	}
+
+	return createResult(bytesToJSArray(opened), nil)
+}
 
-	fmt.Printf("Decrypting with algorithm: %s\n", algorithm)
-	fmt.Printf("Encrypted data (base64): %s\n", args[2].String())
-	fmt.Printf("IV (base64): %s\n", args[3].String())
+// dhKey is this participant's key pair for the current handshake. The
+// private half never leaves the module.
+var dhKey *crypto.DHKey
 
-	// Decode base64 content
-	encryptedBytes, err := base64.StdEncoding.DecodeString(args[2].String())
+// generateDHKey() starts a new handshake and returns the base64 public value
+// to send to the other participant
+func generateDHKey(this js.Value, args []js.Value) interface{} {
+	key, err := crypto.GenerateDHKey(crypto.DHGroup14)
 	if err != nil {
-		return createResult(nil, fmt.Errorf("invalid base64 content: %v", err))
+		return createResult(nil, fmt.Errorf("key generation failed: %w", err))
 	}
+	dhKey = key
 
-	// Decode base64 IV
-	iv, err := base64.StdEncoding.DecodeString(args[3].String())
+	return createResult(base64.StdEncoding.EncodeToString(key.Public()), nil)
+}
+
+// deriveSessionKey(algorithm, peerPublicBase64, password, saltBase64)
+// completes the handshake and derives the session key from the shared
+// secret, the room password and both public values
+func deriveSessionKey(this js.Value, args []js.Value) interface{} {
+	if len(args) < 4 {
+		return createResult(nil, fmt.Errorf("invalid number of arguments"))
+	}
+	if dhKey == nil {
+		return createResult(nil, fmt.Errorf("no key exchange in progress"))
+	}
+
+	peerPublic, err := base64.StdEncoding.DecodeString(args[1].String())
 	if err != nil {
-		return createResult(nil, fmt.Errorf("invalid base64 IV: %v", err))
+		return createResult(nil, fmt.Errorf("invalid base64 public key: %v", err))
 	}
 
-	fmt.Printf("Decoded encrypted data length: %d\n", len(encryptedBytes))
-	fmt.Printf("Decoded IV length: %d\n", len(iv))
+	password := []byte(args[2].String())
 
-	// Create cipher
-	cipher, err := crypto.NewCipher(algorithm, key)
+	salt, err := base64.StdEncoding.DecodeString(args[3].String())
 	if err != nil {
-		return createResult(nil, fmt.Errorf("cipher creation failed: %v", err))
+		return createResult(nil, fmt.Errorf("invalid base64 salt: %v", err))
 	}
 
-	// Decrypt data
-	decrypted := cipher.DecryptCBC(encryptedBytes, iv)
-	if decrypted == nil {
-		return createResult(nil, fmt.Errorf("decryption failed"))
+	secret, err := dhKey.SharedSecret(peerPublic)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("key exchange failed: %w", err))
 	}
 
-	decryptedStr := string(decrypted)
-	fmt.Printf("Decrypted result: %s\n", decryptedStr)
+	transcript := crypto.Transcript(dhKey.Public(), peerPublic)
+	key, err := crypto.DeriveSessionKey(args[0].String(), secret, password, salt, transcript)
+	if err != nil {
+		return createResult(nil, fmt.Errorf("key derivation failed: %w", err))
+	}
 
-	return createResult(decryptedStr, nil)
+	return createResult(bytesToJSArray(key), nil)
 }
 
 func main() {
@@ -129,6 +303,10 @@ func main() {
 
 	js.Global().Set("encryptMessage", js.FuncOf(encrypt))
 	js.Global().Set("decryptMessage", js.FuncOf(decrypt))
+	js.Global().Set("sealMessage", js.FuncOf(sealMessage))
+	js.Global().Set("openMessage", js.FuncOf(openMessage))
+	js.Global().Set("generateDHKey", js.FuncOf(generateDHKey))
+	js.Global().Set("deriveSessionKey", js.FuncOf(deriveSessionKey))
 
 	<-c
 }
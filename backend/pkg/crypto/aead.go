@@ -0,0 +1,111 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+)
+
+// TagSize is the length of the authentication tag appended by Seal
+const TagSize = sha256.Size
+
+// Labels used to split a room key into independent encryption and MAC keys
+var (
+	encKeyLabel = []byte("CryptographyCW encryption key")
+	macKeyLabel = []byte("CryptographyCW authentication key")
+)
+
+// EncryptThenMAC provides authenticated encryption on top of any Cipher.
+// Data is encrypted with the given mode and padding, then HMAC-SHA256 is
+// computed over the associated data, the IV and the ciphertext and appended.
+type EncryptThenMAC struct {
+	c       Cipher
+	mode    string
+	padding PaddingType
+	macKey  []byte
+}
+
+// NewEncryptThenMAC wraps c, which must already be keyed, using macKey for authentication
+func NewEncryptThenMAC(c Cipher, mode string, padding PaddingType, macKey []byte) (*EncryptThenMAC, error) {
+	if _, err := GetMode(c, mode); err != nil {
+		return nil, err
+	}
+	if _, err := Pad(nil, c.BlockSize(), padding); err != nil {
+		return nil, err
+	}
+
+	return &EncryptThenMAC{
+		c:       c,
+		mode:    mode,
+		padding: padding,
+		macKey:  append([]byte(nil), macKey...),
+	}, nil
+}
+
+// NewAEAD creates an EncryptThenMAC for the algorithm, deriving separate
+// encryption and MAC keys from key
+func NewAEAD(algorithm string, key []byte, mode string, padding PaddingType) (*EncryptThenMAC, error) {
+	encKey := subkey(key, encKeyLabel, minInt(len(key), sha256.Size))
+	macKey := subkey(key, macKeyLabel, sha256.Size)
+
+	c, err := NewCipher(algorithm, encKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptThenMAC(c, mode, padding, macKey)
+}
+
+// Seal encrypts plaintext and appends a tag authenticating it together with ad
+func (a *EncryptThenMAC) Seal(plaintext, iv, ad []byte) ([]byte, error) {
+	ciphertext, err := a.c.EncryptWithMode(plaintext, iv, a.mode, a.padding)
+	if err != nil {
+		return nil, err
+	}
+	return append(ciphertext, a.tag(ciphertext, iv, ad)...), nil
+}
+
+// Open verifies the tag and decrypts. Nothing is decrypted if the ciphertext,
+// IV or associated data were modified.
+func (a *EncryptThenMAC) Open(sealed, iv, ad []byte) ([]byte, error) {
+	if len(sealed) < TagSize {
+		return nil, ErrAuthenticationFailed
+	}
+
+	ciphertext, tag := sealed[:len(sealed)-TagSize], sealed[len(sealed)-TagSize:]
+	if !hmac.Equal(tag, a.tag(ciphertext, iv, ad)) {
+		return nil, ErrAuthenticationFailed
+	}
+
+	return a.c.DecryptWithMode(ciphertext, iv, a.mode, a.padding)
+}
+
+// tag computes HMAC-SHA256 over the length-prefixed ad, iv and ciphertext
+func (a *EncryptThenMAC) tag(ciphertext, iv, ad []byte) []byte {
+	mac := hmac.New(sha256.New, a.macKey)
+	for _, part := range [][]byte{ad, iv, ciphertext} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		mac.Write(length[:])
+		mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
+
+// AssociatedData encodes the message fields covered by the tag. The time is
+// reduced to milliseconds so it survives a JSON round trip through the server.
+func AssociatedData(from string, sentAt time.Time, msgType string) []byte {
+	ad := make([]byte, 0, binary.MaxVarintLen64+len(from)+8+len(msgType))
+	ad = binary.AppendUvarint(ad, uint64(len(from)))
+	ad = append(ad, from...)
+	ad = binary.BigEndian.AppendUint64(ad, uint64(sentAt.UnixMilli()))
+	ad = append(ad, msgType...)
+	return ad
+}
+
+// subkey derives n bytes (at most sha256.Size) from key for the given purpose
+func subkey(key, label []byte, n int) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(label)
+	return mac.Sum(nil)[:n]
+}
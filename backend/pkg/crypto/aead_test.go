@@ -0,0 +1,124 @@
+package crypto_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+// countingCipher records calls to DecryptWithMode so tests can tell
+// whether Open reached the decryption step
+type countingCipher struct {
+	crypto.Cipher
+	decrypts int
+}
+
+func (c *countingCipher) DecryptWithMode(ciphertext, iv []byte, mode string, padding crypto.PaddingType) ([]byte, error) {
+	c.decrypts++
+	return c.Cipher.DecryptWithMode(ciphertext, iv, mode, padding)
+}
+
+func TestAEADOpen(t *testing.T) {
+	sentAt := time.UnixMilli(1700000000123)
+	ad := crypto.AssociatedData("alice", sentAt, "text")
+	iv := testKey(16)
+	plaintext := testMessage(100)
+
+	flip := func(b []byte, i int) []byte {
+		b = bytes.Clone(b)
+		b[i] ^= 0x01
+		return b
+	}
+
+	tests := []struct {
+		name string
+		// tamper returns the sealed message, IV and AD passed to Open
+		tamper func(sealed []byte) ([]byte, []byte, []byte)
+	}{
+		{"ciphertext bit", func(s []byte) ([]byte, []byte, []byte) { return flip(s, 5), iv, ad }},
+		{"last ciphertext bit", func(s []byte) ([]byte, []byte, []byte) { return flip(s, len(s)-crypto.TagSize-1), iv, ad }},
+		{"tag bit", func(s []byte) ([]byte, []byte, []byte) { return flip(s, len(s)-1), iv, ad }},
+		{"iv bit", func(s []byte) ([]byte, []byte, []byte) { return s, flip(iv, 0), ad }},
+		{"from", func(s []byte) ([]byte, []byte, []byte) {
+			return s, iv, crypto.AssociatedData("mallory", sentAt, "text")
+		}},
+		{"sentAt", func(s []byte) ([]byte, []byte, []byte) {
+			return s, iv, crypto.AssociatedData("alice", sentAt.Add(time.Millisecond), "text")
+		}},
+		{"msgType", func(s []byte) ([]byte, []byte, []byte) {
+			return s, iv, crypto.AssociatedData("alice", sentAt, "file")
+		}},
+		{"tag only", func(s []byte) ([]byte, []byte, []byte) { return s[len(s)-crypto.TagSize:], iv, ad }},
+		{"below tag size", func(s []byte) ([]byte, []byte, []byte) { return s[:crypto.TagSize-1], iv, ad }},
+		{"empty", func(s []byte) ([]byte, []byte, []byte) { return nil, iv, ad }},
+	}
+
+	for _, mode := range crypto.Modes {
+		block, err := crypto.NewCipher("AES", testKey(32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &countingCipher{Cipher: block}
+		aead, err := crypto.NewEncryptThenMAC(c, mode, crypto.PKCS7, testKey(32))
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+
+		sealed, err := aead.Seal(plaintext, iv, ad)
+		if err != nil {
+			t.Fatalf("%s: Seal: %v", mode, err)
+		}
+		got, err := aead.Open(sealed, iv, ad)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Fatalf("%s: round trip: %v", mode, err)
+		}
+
+		for _, tt := range tests {
+			c.decrypts = 0
+			s, tiv, tad := tt.tamper(sealed)
+			if _, err := aead.Open(s, tiv, tad); !errors.Is(err, crypto.ErrAuthenticationFailed) {
+				t.Errorf("%s, %s: err = %v, want ErrAuthenticationFailed", mode, tt.name, err)
+			}
+			if c.decrypts != 0 {
+				t.Errorf("%s, %s: ciphertext decrypted before the tag was checked", mode, tt.name)
+			}
+		}
+	}
+}
+
+func TestAEADKeySeparation(t *testing.T) {
+	key := testKey(32)
+	iv := testKey(16)
+	a, err := crypto.NewAEAD("AES", key, "CBC", crypto.PKCS7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := a.Seal(testMessage(40), iv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The encryption key is derived from the room key, not the room key itself
+	block, err := crypto.NewCipher("AES", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := block.EncryptWithMode(testMessage(40), iv, "CBC", crypto.PKCS7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sealed[:len(plain)], plain) {
+		t.Error("NewAEAD encrypts with the raw key")
+	}
+
+	other, err := crypto.NewAEAD("AES", bytes.Repeat([]byte{0x42}, 32), "CBC", crypto.PKCS7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Open(sealed, iv, nil); !errors.Is(err, crypto.ErrAuthenticationFailed) {
+		t.Errorf("wrong key: err = %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package crypto
+
+import "crypto/cipher"
+
+// blockCipher is implemented by ciphers that can process a single block
+// in place, without allocating
+type blockCipher interface {
+	BlockSize() int
+	encryptBlock(dst, src []byte)
+	decryptBlock(dst, src []byte)
+}
+
+// blockModes implements the rest of Cipher on top of a blockCipher.
+// A cipher embeds it, pointing it back at itself, and only has to provide
+// BlockSize, encryptBlock and decryptBlock.
+type blockModes struct {
+	blockCipher
+}
+
+// Encrypt encrypts a single block
+func (m blockModes) Encrypt(block []byte) ([]byte, error) {
+	if len(block) != m.BlockSize() {
+		return nil, ErrInvalidBlockSize
+	}
+
+	out := make([]byte, len(block))
+	m.encryptBlock(out, block)
+	return out, nil
+}
+
+// Decrypt decrypts a single block
+func (m blockModes) Decrypt(block []byte) ([]byte, error) {
+	if len(block) != m.BlockSize() {
+		return nil, ErrInvalidBlockSize
+	}
+
+	out := make([]byte, len(block))
+	m.decryptBlock(out, block)
+	return out, nil
+}
+
+// EncryptCBC encrypts data using CBC mode with PKCS7 padding
+func (m blockModes) EncryptCBC(data []byte, iv []byte) ([]byte, error) {
+	return m.EncryptWithMode(data, iv, "CBC", PKCS7)
+}
+
+// DecryptCBC decrypts data using CBC mode with PKCS7 padding
+func (m blockModes) DecryptCBC(ciphertext []byte, iv []byte) ([]byte, error) {
+	return m.DecryptWithMode(ciphertext, iv, "CBC", PKCS7)
+}
+
+// EncryptWithMode encrypts data using the specified mode and padding
+func (m blockModes) EncryptWithMode(data []byte, iv []byte, mode string, padding PaddingType) ([]byte, error) {
+	// Get the mode implementation
+	modeImpl, err := GetMode(m, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add padding
+	paddedData, err := Pad(data, m.BlockSize(), padding)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt using the selected mode
+	return modeImpl.Encrypt(paddedData, iv)
+}
+
+// DecryptWithMode decrypts data using the specified mode and padding
+func (m blockModes) DecryptWithMode(ciphertext []byte, iv []byte, mode string, padding PaddingType) ([]byte, error) {
+	// Padding always fills the last block, so any valid ciphertext is aligned
+	if len(ciphertext)%m.BlockSize() != 0 {
+		return nil, ErrInvalidBlockSize
+	}
+
+	// Get the mode implementation
+	modeImpl, err := GetMode(m, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt using the selected mode
+	decrypted, err := modeImpl.Decrypt(ciphertext, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove padding
+	return Unpad(decrypted, m.BlockSize(), padding)
+}
+
+// Block adapts a Cipher to the standard library cipher.Block interface,
+// so it can be used with crypto/cipher modes and stream wrappers.
+// As with the standard library ciphers, Encrypt and Decrypt panic if
+// src or dst is shorter than one block.
+type Block struct {
+	c Cipher
+}
+
+// NewBlock wraps c as a cipher.Block
+func NewBlock(c Cipher) cipher.Block {
+	return &Block{c: c}
+}
+
+// NewRC5Block creates an RC5-w/r/b cipher as a cipher.Block
+func NewRC5Block(wordSize, rounds int, key []byte) (cipher.Block, error) {
+	c, err := NewRC5(wordSize, rounds, key)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlock(c), nil
+}
+
+// NewTwoFishBlock creates a TwoFish cipher as a cipher.Block
+func NewTwoFishBlock(key []byte) (cipher.Block, error) {
+	c, err := NewTwoFish(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlock(c), nil
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (b *Block) BlockSize() int {
+	return b.c.BlockSize()
+}
+
+// Encrypt encrypts the first block of src into dst. Dst and src may overlap.
+func (b *Block) Encrypt(dst, src []byte) {
+	bs := b.checkBuffers(dst, src)
+	if bc, ok := b.c.(blockCipher); ok {
+		bc.encryptBlock(dst[:bs], src[:bs])
+		return
+	}
+
+	out, err := b.c.Encrypt(src[:bs])
+	if err != nil {
+		panic("crypto: " + err.Error())
+	}
+	copy(dst, out)
+}
+
+// Decrypt decrypts the first block of src into dst. Dst and src may overlap.
+func (b *Block) Decrypt(dst, src []byte) {
+	bs := b.checkBuffers(dst, src)
+	if bc, ok := b.c.(blockCipher); ok {
+		bc.decryptBlock(dst[:bs], src[:bs])
+		return
+	}
+
+	out, err := b.c.Decrypt(src[:bs])
+	if err != nil {
+		panic("crypto: " + err.Error())
+	}
+	copy(dst, out)
+}
+
+func (b *Block) checkBuffers(dst, src []byte) int {
+	bs := b.c.BlockSize()
+	if len(src) < bs {
+		panic("crypto: input not full block")
+	}
+	if len(dst) < bs {
+		panic("crypto: output not full block")
+	}
+	return bs
+}
@@ -1,49 +1,77 @@
 package crypto
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrUnsupportedAlgorithm = errors.New("unsupported encryption algorithm")
 	ErrUnsupportedMode      = errors.New("unsupported cipher mode")
 	ErrUnsupportedPadding   = errors.New("unsupported padding type")
+	ErrInvalidPadding       = errors.New("invalid padding")
+	ErrInvalidBlockSize     = errors.New("invalid block size")
+	ErrInvalidIV            = errors.New("invalid IV length")
+	ErrInvalidKeySize       = errors.New("invalid key size")
+	ErrStreamClosed         = errors.New("write to closed stream")
+	ErrInvalidSalt          = errors.New("salt must be at least 8 bytes")
+	ErrAuthenticationFailed = errors.New("message authentication failed")
+	ErrInvalidPublicKey     = errors.New("invalid public key")
 )
 
 // Cipher represents a common interface for encryption algorithms
 type Cipher interface {
+	// BlockSize returns the cipher's block size in bytes
+	BlockSize() int
+
 	// Encrypt encrypts a block of data
-	Encrypt(block []byte) []byte
+	Encrypt(block []byte) ([]byte, error)
 
 	// Decrypt decrypts a block of data
-	Decrypt(block []byte) []byte
+	Decrypt(block []byte) ([]byte, error)
 
 	// EncryptCBC encrypts data using CBC mode
-	EncryptCBC(data []byte, iv []byte) []byte
+	EncryptCBC(data []byte, iv []byte) ([]byte, error)
 
 	// DecryptCBC decrypts data using CBC mode
-	DecryptCBC(ciphertext []byte, iv []byte) []byte
+	DecryptCBC(ciphertext []byte, iv []byte) ([]byte, error)
 
 	// EncryptWithMode encrypts data using the specified mode and padding
-	EncryptWithMode(data []byte, iv []byte, mode string, padding PaddingType) []byte
+	EncryptWithMode(data []byte, iv []byte, mode string, padding PaddingType) ([]byte, error)
 
 	// DecryptWithMode decrypts data using the specified mode and padding
-	DecryptWithMode(ciphertext []byte, iv []byte, mode string, padding PaddingType) []byte
+	DecryptWithMode(ciphertext []byte, iv []byte, mode string, padding PaddingType) ([]byte, error)
 }
 
-// NewCipher creates a new cipher instance based on the algorithm and key
+// NewCipher creates a new cipher instance based on the algorithm and key.
+// The algorithm is a registered name, optionally followed by a parameter,
+// e.g. "RC5-64/16" or "Rijndael-256/11D". The key length must be one of the
+// algorithm's KeySizes.
 func NewCipher(algorithm string, key []byte) (Cipher, error) {
-	switch algorithm {
-	case "RC5":
-		return New(12, key)
-	case "TwoFish":
-		return NewTwoFish(key)
-	default:
+	a, ok := LookupAlgorithm(algorithm)
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	_, param, hasParam := strings.Cut(algorithm, "/")
+	if hasParam && (a.Parameter == "" || param == "") {
 		return nil, ErrUnsupportedAlgorithm
 	}
+	if !a.KeySizes.Valid(len(key)) {
+		return nil, fmt.Errorf("%w: %d bytes for %s", ErrInvalidKeySize, len(key), a.Name)
+	}
+	return a.New(key, param)
 }
 
+// Modes lists the mode names accepted by GetMode
+var Modes = []string{"ECB", "CBC", "PCBC", "CFB", "OFB", "CTR", "RandomDelta"}
+
 // GetMode returns a Mode instance for the specified mode name
 func GetMode(c Cipher, mode string) (Mode, error) {
 	switch mode {
+	case "ECB":
+		return NewECBMode(c), nil
 	case "CBC":
 		return NewCBCMode(c), nil
 	case "PCBC":
@@ -54,6 +82,8 @@ func GetMode(c Cipher, mode string) (Mode, error) {
 		return NewOFBMode(c), nil
 	case "CTR":
 		return NewCTRMode(c), nil
+	case "RandomDelta":
+		return NewRandomDeltaMode(c), nil
 	default:
 		return nil, ErrUnsupportedMode
 	}
@@ -0,0 +1,127 @@
+package crypto_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+// errCase is a call expected to fail with err
+type errCase struct {
+	name string
+	err  error
+	fn   func() error
+}
+
+// noPanic runs fn and turns a panic into a test failure
+func noPanic(t *testing.T, name string, fn func() error) error {
+	t.Helper()
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("%s panicked: %v", name, r)
+			}
+		}()
+		err = fn()
+	}()
+	return err
+}
+
+func TestCipherErrors(t *testing.T) {
+	for _, alg := range crypto.Algorithms() {
+		c, err := crypto.NewCipher(alg.Name, testKey(alg.KeySize))
+		if err != nil {
+			t.Fatalf("%s: %v", alg.Name, err)
+		}
+		bs := c.BlockSize()
+		iv := testMessage(bs)
+		ct, err := c.EncryptWithMode(testMessage(3*bs), iv, "CBC", crypto.PKCS7)
+		if err != nil {
+			t.Fatalf("%s: %v", alg.Name, err)
+		}
+
+		tests := []errCase{
+			{"Encrypt short block", crypto.ErrInvalidBlockSize, func() error {
+				_, err := c.Encrypt(make([]byte, bs-1))
+				return err
+			}},
+			{"Decrypt long block", crypto.ErrInvalidBlockSize, func() error {
+				_, err := c.Decrypt(make([]byte, bs+1))
+				return err
+			}},
+			{"unknown mode", crypto.ErrUnsupportedMode, func() error {
+				_, err := c.EncryptWithMode(testMessage(bs), iv, "XTS", crypto.PKCS7)
+				return err
+			}},
+			{"unknown mode on decrypt", crypto.ErrUnsupportedMode, func() error {
+				_, err := c.DecryptWithMode(ct, iv, "XTS", crypto.PKCS7)
+				return err
+			}},
+			{"unknown padding", crypto.ErrUnsupportedPadding, func() error {
+				_, err := c.EncryptWithMode(testMessage(bs), iv, "CBC", "PKCS5")
+				return err
+			}},
+			{"unknown padding on decrypt", crypto.ErrUnsupportedPadding, func() error {
+				_, err := c.DecryptWithMode(ct, iv, "CBC", "PKCS5")
+				return err
+			}},
+			{"nil IV", crypto.ErrInvalidIV, func() error {
+				_, err := c.EncryptCBC(testMessage(bs), nil)
+				return err
+			}},
+		}
+		for _, mode := range crypto.Modes {
+			if mode != "ECB" {
+				tests = append(tests, errCase{mode + " short IV", crypto.ErrInvalidIV, func() error {
+					_, err := c.DecryptWithMode(ct, iv[:bs-1], mode, crypto.PKCS7)
+					return err
+				}})
+			}
+			tests = append(tests, errCase{mode + " misaligned ciphertext", crypto.ErrInvalidBlockSize, func() error {
+				_, err := c.DecryptWithMode(ct[:len(ct)-1], iv, mode, crypto.PKCS7)
+				return err
+			}})
+		}
+
+		for _, tt := range tests {
+			name := fmt.Sprintf("%s %s", alg.Name, tt.name)
+			if err := noPanic(t, name, tt.fn); !errors.Is(err, tt.err) {
+				t.Errorf("%s: err = %v, want %v", name, err, tt.err)
+			}
+		}
+	}
+}
+
+func TestGetModeErrors(t *testing.T) {
+	c, err := crypto.NewTwoFish(testKey(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []string{"", "cbc", "XTS", "GCM"} {
+		if _, err := crypto.GetMode(c, mode); !errors.Is(err, crypto.ErrUnsupportedMode) {
+			t.Errorf("GetMode(%q): err = %v", mode, err)
+		}
+	}
+	if _, err := crypto.NewCipher("Blowfish", testKey(16)); !errors.Is(err, crypto.ErrUnsupportedAlgorithm) {
+		t.Errorf("NewCipher(Blowfish): err = %v", err)
+	}
+}
+
+// TestNewCipherKeySizes checks NewCipher against each algorithm's KeySizes
+func TestNewCipherKeySizes(t *testing.T) {
+	for _, alg := range crypto.Algorithms() {
+		for n := 0; n <= 64; n++ {
+			_, err := crypto.NewCipher(alg.Name, testKey(n))
+			if alg.KeySizes.Valid(n) {
+				if err != nil {
+					t.Errorf("%s, %d-byte key: %v", alg.Name, n, err)
+				}
+			} else if !errors.Is(err, crypto.ErrInvalidKeySize) {
+				t.Errorf("%s, %d-byte key: err = %v, want ErrInvalidKeySize", alg.Name, n, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package crypto_test
+
+import (
+	"testing"
+
+	"CryptographyCW/pkg/crypto/kat"
+)
+
+// checkVectors runs the built-in known-answer vectors from one testdata file
+func checkVectors(t *testing.T, file string) {
+	t.Helper()
+
+	vectors, err := kat.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for _, v := range vectors {
+		if v.File != file {
+			continue
+		}
+		n++
+		if err := kat.Run(v); err != nil {
+			t.Error(err)
+		}
+	}
+	if n == 0 {
+		t.Fatalf("no vectors in %s", file)
+	}
+}
@@ -0,0 +1,438 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Constants for DES and the ciphers built on it
+const (
+	// DESBlockSize is the DES and Triple-DES block size in bytes
+	DESBlockSize = 8
+	// DEALBlockSize is the DEAL block size in bytes
+	DEALBlockSize = 16
+
+	desRounds     = 16
+	desSubkeySize = 6 // 48-bit round keys
+)
+
+// DES permutation tables (from FIPS 46-3). Bits are numbered from 1, starting
+// with the most significant bit.
+var (
+	desIP = [64]byte{
+		58, 50, 42, 34, 26, 18, 10, 2,
+		60, 52, 44, 36, 28, 20, 12, 4,
+		62, 54, 46, 38, 30, 22, 14, 6,
+		64, 56, 48, 40, 32, 24, 16, 8,
+		57, 49, 41, 33, 25, 17, 9, 1,
+		59, 51, 43, 35, 27, 19, 11, 3,
+		61, 53, 45, 37, 29, 21, 13, 5,
+		63, 55, 47, 39, 31, 23, 15, 7,
+	}
+
+	desFP = [64]byte{
+		40, 8, 48, 16, 56, 24, 64, 32,
+		39, 7, 47, 15, 55, 23, 63, 31,
+		38, 6, 46, 14, 54, 22, 62, 30,
+		37, 5, 45, 13, 53, 21, 61, 29,
+		36, 4, 44, 12, 52, 20, 60, 28,
+		35, 3, 43, 11, 51, 19, 59, 27,
+		34, 2, 42, 10, 50, 18, 58, 26,
+		33, 1, 41, 9, 49, 17, 57, 25,
+	}
+
+	// Expansion of the 32-bit half to 48 bits
+	desE = [48]byte{
+		32, 1, 2, 3, 4, 5,
+		4, 5, 6, 7, 8, 9,
+		8, 9, 10, 11, 12, 13,
+		12, 13, 14, 15, 16, 17,
+		16, 17, 18, 19, 20, 21,
+		20, 21, 22, 23, 24, 25,
+		24, 25, 26, 27, 28, 29,
+		28, 29, 30, 31, 32, 1,
+	}
+
+	// Permutation of the S-box output
+	desP = [32]byte{
+		16, 7, 20, 21, 29, 12, 28, 17,
+		1, 15, 23, 26, 5, 18, 31, 10,
+		2, 8, 24, 14, 32, 27, 3, 9,
+		19, 13, 30, 6, 22, 11, 4, 25,
+	}
+
+	// Permuted choice 1, selecting 56 key bits
+	desPC1 = [56]byte{
+		57, 49, 41, 33, 25, 17, 9,
+		1, 58, 50, 42, 34, 26, 18,
+		10, 2, 59, 51, 43, 35, 27,
+		19, 11, 3, 60, 52, 44, 36,
+		63, 55, 47, 39, 31, 23, 15,
+		7, 62, 54, 46, 38, 30, 22,
+		14, 6, 61, 53, 45, 37, 29,
+		21, 13, 5, 28, 20, 12, 4,
+	}
+
+	// Permuted choice 2, selecting the 48 round key bits
+	desPC2 = [48]byte{
+		14, 17, 11, 24, 1, 5,
+		3, 28, 15, 6, 21, 10,
+		23, 19, 12, 4, 26, 8,
+		16, 7, 27, 20, 13, 2,
+		41, 52, 31, 37, 47, 55,
+		30, 40, 51, 45, 33, 48,
+		44, 49, 39, 56, 34, 53,
+		46, 42, 50, 36, 29, 32,
+	}
+
+	// Left rotations of the key halves before each round
+	desShifts = [desRounds]uint{1, 1, 2, 2, 2, 2, 2, 2, 1, 2, 2, 2, 2, 2, 2, 1}
+)
+
+// DES S-boxes, indexed by row (outer bits) and column (inner bits)
+var desSBox = [8][4][16]byte{
+	{
+		{14, 4, 13, 1, 2, 15, 11, 8, 3, 10, 6, 12, 5, 9, 0, 7},
+		{0, 15, 7, 4, 14, 2, 13, 1, 10, 6, 12, 11, 9, 5, 3, 8},
+		{4, 1, 14, 8, 13, 6, 2, 11, 15, 12, 9, 7, 3, 10, 5, 0},
+		{15, 12, 8, 2, 4, 9, 1, 7, 5, 11, 3, 14, 10, 0, 6, 13},
+	},
+	{
+		{15, 1, 8, 14, 6, 11, 3, 4, 9, 7, 2, 13, 12, 0, 5, 10},
+		{3, 13, 4, 7, 15, 2, 8, 14, 12, 0, 1, 10, 6, 9, 11, 5},
+		{0, 14, 7, 11, 10, 4, 13, 1, 5, 8, 12, 6, 9, 3, 2, 15},
+		{13, 8, 10, 1, 3, 15, 4, 2, 11, 6, 7, 12, 0, 5, 14, 9},
+	},
+	{
+		{10, 0, 9, 14, 6, 3, 15, 5, 1, 13, 12, 7, 11, 4, 2, 8},
+		{13, 7, 0, 9, 3, 4, 6, 10, 2, 8, 5, 14, 12, 11, 15, 1},
+		{13, 6, 4, 9, 8, 15, 3, 0, 11, 1, 2, 12, 5, 10, 14, 7},
+		{1, 10, 13, 0, 6, 9, 8, 7, 4, 15, 14, 3, 11, 5, 2, 12},
+	},
+	{
+		{7, 13, 14, 3, 0, 6, 9, 10, 1, 2, 8, 5, 11, 12, 4, 15},
+		{13, 8, 11, 5, 6, 15, 0, 3, 4, 7, 2, 12, 1, 10, 14, 9},
+		{10, 6, 9, 0, 12, 11, 7, 13, 15, 1, 3, 14, 5, 2, 8, 4},
+		{3, 15, 0, 6, 10, 1, 13, 8, 9, 4, 5, 11, 12, 7, 2, 14},
+	},
+	{
+		{2, 12, 4, 1, 7, 10, 11, 6, 8, 5, 3, 15, 13, 0, 14, 9},
+		{14, 11, 2, 12, 4, 7, 13, 1, 5, 0, 15, 10, 3, 9, 8, 6},
+		{4, 2, 1, 11, 10, 13, 7, 8, 15, 9, 12, 5, 6, 3, 0, 14},
+		{11, 8, 12, 7, 1, 14, 2, 13, 6, 15, 0, 9, 10, 4, 5, 3},
+	},
+	{
+		{12, 1, 10, 15, 9, 2, 6, 8, 0, 13, 3, 4, 14, 7, 5, 11},
+		{10, 15, 4, 2, 7, 12, 9, 5, 6, 1, 13, 14, 0, 11, 3, 8},
+		{9, 14, 15, 5, 2, 8, 12, 3, 7, 0, 4, 10, 1, 13, 11, 6},
+		{4, 3, 2, 12, 9, 5, 15, 10, 11, 14, 1, 7, 6, 0, 8, 13},
+	},
+	{
+		{4, 11, 2, 14, 15, 0, 8, 13, 3, 12, 9, 7, 5, 10, 6, 1},
+		{13, 0, 11, 7, 4, 9, 1, 10, 14, 3, 5, 12, 2, 15, 8, 6},
+		{1, 4, 11, 13, 12, 3, 7, 14, 10, 15, 6, 8, 0, 5, 9, 2},
+		{6, 11, 13, 8, 1, 4, 10, 7, 9, 5, 0, 15, 14, 2, 3, 12},
+	},
+	{
+		{13, 2, 8, 4, 6, 15, 11, 1, 10, 9, 3, 14, 5, 0, 12, 7},
+		{1, 15, 13, 8, 10, 3, 7, 4, 12, 5, 6, 11, 0, 14, 9, 2},
+		{7, 11, 4, 1, 9, 12, 14, 2, 0, 6, 10, 13, 15, 3, 5, 8},
+		{2, 1, 14, 7, 4, 10, 8, 13, 15, 12, 9, 0, 3, 5, 6, 11},
+	},
+}
+
+// permuteBits builds a value from the bits of the inBits-wide value in
+// selected by table, where bit 1 is the most significant
+func permuteBits(in uint64, inBits uint, table []byte) uint64 {
+	var out uint64
+	for _, pos := range table {
+		out = out<<1 | (in>>(inBits-uint(pos)))&1
+	}
+	return out
+}
+
+// desKeySchedule derives the sixteen 48-bit DES round keys. The parity bits
+// of the key are ignored.
+type desKeySchedule struct{}
+
+// RoundKeys implements KeySchedule
+func (desKeySchedule) RoundKeys(key []byte) ([][]byte, error) {
+	if len(key) != 8 {
+		return nil, errors.New("des: key must be 8 bytes")
+	}
+
+	const mask28 = 1<<28 - 1
+	cd := permuteBits(binary.BigEndian.Uint64(key), 64, desPC1[:])
+	c, d := cd>>28, cd&mask28
+
+	keys := make([][]byte, desRounds)
+	for i, shift := range desShifts {
+		c = (c<<shift | c>>(28-shift)) & mask28
+		d = (d<<shift | d>>(28-shift)) & mask28
+		k := permuteBits(c<<28|d, 56, desPC2[:])
+
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], k)
+		keys[i] = append([]byte(nil), buf[8-desSubkeySize:]...)
+	}
+	return keys, nil
+}
+
+// desRound is the DES F function: expansion, key mixing, S-boxes and P
+type desRound struct{}
+
+// Apply implements RoundFunction
+func (desRound) Apply(dst, half, roundKey []byte) {
+	var k uint64
+	for _, b := range roundKey {
+		k = k<<8 | uint64(b)
+	}
+
+	x := permuteBits(uint64(binary.BigEndian.Uint32(half)), 32, desE[:]) ^ k
+
+	var s uint64
+	for i := 0; i < 8; i++ {
+		six := (x >> (42 - 6*i)) & 0x3F
+		row := (six>>4)&2 | six&1
+		col := (six >> 1) & 0xF
+		s = s<<4 | uint64(desSBox[i][row][col])
+	}
+
+	binary.BigEndian.PutUint32(dst, uint32(permuteBits(s, 32, desP[:])))
+}
+
+// desCrypt runs DES over one block: the initial permutation, the rounds of
+// net and the final permutation
+func desCrypt(net *Feistel, dst, src []byte, decrypt bool) {
+	var buf [DESBlockSize]byte
+	binary.BigEndian.PutUint64(buf[:], permuteBits(binary.BigEndian.Uint64(src), 64, desIP[:]))
+
+	net.crypt(buf[:], buf[:], decrypt)
+
+	binary.BigEndian.PutUint64(dst, permuteBits(binary.BigEndian.Uint64(buf[:]), 64, desFP[:]))
+}
+
+// DES represents a DES cipher instance
+type DES struct {
+	blockModes
+	net *Feistel
+}
+
+func init() {
+	Register(Algorithm{
+		Name:      "DES",
+		BlockSize: DESBlockSize,
+		KeySizes:  KeySizes{Min: 8, Max: 8, Step: 8},
+		KeySize:   8,
+		New: func(key []byte, _ string) (Cipher, error) {
+			return NewDES(key)
+		},
+	})
+
+	Register(Algorithm{
+		Name:      "3DES",
+		BlockSize: DESBlockSize,
+		KeySizes:  KeySizes{Min: 16, Max: 24, Step: 8},
+		KeySize:   24,
+		New: func(key []byte, _ string) (Cipher, error) {
+			return NewTripleDES(key)
+		},
+	})
+
+	Register(Algorithm{
+		Name:      "DEAL",
+		BlockSize: DEALBlockSize,
+		KeySizes:  KeySizes{Min: 16, Max: 32, Step: 8},
+		KeySize:   32,
+		New: func(key []byte, _ string) (Cipher, error) {
+			return NewDEAL(key)
+		},
+	})
+}
+
+// NewDES creates a new DES cipher instance from an 8-byte key
+func NewDES(key []byte) (*DES, error) {
+	net, err := NewFeistel(DESBlockSize, desKeySchedule{}, desRound{}, key)
+	if err != nil {
+		return nil, err
+	}
+	d := &DES{net: net}
+	d.blockModes = blockModes{d}
+	return d, nil
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (d *DES) BlockSize() int {
+	return DESBlockSize
+}
+
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (d *DES) encryptBlock(dst, src []byte) {
+	desCrypt(d.net, dst, src, false)
+}
+
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (d *DES) decryptBlock(dst, src []byte) {
+	desCrypt(d.net, dst, src, true)
+}
+
+// TripleDES represents a Triple-DES cipher instance in EDE mode
+type TripleDES struct {
+	blockModes
+	k1, k2, k3 *DES
+}
+
+// NewTripleDES creates a new Triple-DES (EDE) cipher instance. A 24-byte key
+// holds three independent DES keys; a 16-byte key holds K1 and K2 and
+// reuses K1 as K3.
+func NewTripleDES(key []byte) (*TripleDES, error) {
+	if len(key) != 16 && len(key) != 24 {
+		return nil, errors.New("3des: key must be 16 or 24 bytes")
+	}
+
+	k3 := key[:8]
+	if len(key) == 24 {
+		k3 = key[16:24]
+	}
+
+	var t TripleDES
+	var err error
+	if t.k1, err = NewDES(key[:8]); err != nil {
+		return nil, err
+	}
+	if t.k2, err = NewDES(key[8:16]); err != nil {
+		return nil, err
+	}
+	if t.k3, err = NewDES(k3); err != nil {
+		return nil, err
+	}
+	t.blockModes = blockModes{&t}
+	return &t, nil
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (t *TripleDES) BlockSize() int {
+	return DESBlockSize
+}
+
+// encryptBlock computes E_K3(D_K2(E_K1(src))) into dst, which may overlap src
+func (t *TripleDES) encryptBlock(dst, src []byte) {
+	t.k1.encryptBlock(dst, src)
+	t.k2.decryptBlock(dst, dst)
+	t.k3.encryptBlock(dst, dst)
+}
+
+// decryptBlock computes D_K1(E_K2(D_K3(src))) into dst, which may overlap src
+func (t *TripleDES) decryptBlock(dst, src []byte) {
+	t.k3.decryptBlock(dst, src)
+	t.k2.encryptBlock(dst, dst)
+	t.k1.decryptBlock(dst, dst)
+}
+
+// dealFixedKey is the DES key used by the DEAL key schedule
+var dealFixedKey = []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF}
+
+// dealKeySchedule derives the DEAL round keys RK_i = DES_K(K_i xor c_i xor RK_{i-1}),
+// cycling through the 64-bit key blocks K_i. c_i is zero during the first pass
+// over the key and then sets bit 1, 2, 4 and 8 in turn. Each round key is
+// returned already expanded into its sixteen DES subkeys.
+type dealKeySchedule struct{}
+
+// RoundKeys implements KeySchedule
+func (dealKeySchedule) RoundKeys(key []byte) ([][]byte, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("deal: key must be 16, 24, or 32 bytes")
+	}
+
+	fixed, err := NewDES(dealFixedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s := len(key) / 8
+	rounds := 6
+	if s == 4 {
+		rounds = 8
+	}
+
+	keys := make([][]byte, rounds)
+	var rk [8]byte
+	for i := 0; i < rounds; i++ {
+		var block [8]byte
+		copy(block[:], key[8*(i%s):])
+		if i >= s {
+			bit := 1 << (i - s) // bit number 1, 2, 4 or 8 counted from the left
+			block[(bit-1)/8] ^= 0x80 >> ((bit - 1) % 8)
+		}
+		for j := range block {
+			block[j] ^= rk[j]
+		}
+		fixed.encryptBlock(rk[:], block[:])
+
+		subkeys, err := desKeySchedule{}.RoundKeys(rk[:])
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = make([]byte, 0, desRounds*desSubkeySize)
+		for _, k := range subkeys {
+			keys[i] = append(keys[i], k...)
+		}
+	}
+	return keys, nil
+}
+
+// dealRound uses DES as the round function, keyed with the expanded round key
+type dealRound struct{}
+
+// Apply implements RoundFunction
+func (dealRound) Apply(dst, half, roundKey []byte) {
+	var subkeys [desRounds][]byte
+	for i := range subkeys {
+		subkeys[i] = roundKey[i*desSubkeySize : (i+1)*desSubkeySize]
+	}
+
+	net := Feistel{blockSize: DESBlockSize, f: desRound{}, roundKeys: subkeys[:]}
+	desCrypt(&net, dst, half, false)
+}
+
+// DEAL represents a DEAL cipher instance, a 128-bit Feistel network with DES
+// as the round function. 128 and 192-bit keys use six rounds, 256-bit keys eight.
+type DEAL struct {
+	blockModes
+	net *Feistel
+}
+
+// NewDEAL creates a new DEAL cipher instance
+func NewDEAL(key []byte) (*DEAL, error) {
+	net, err := NewFeistel(DEALBlockSize, dealKeySchedule{}, dealRound{}, key)
+	if err != nil {
+		return nil, err
+	}
+	d := &DEAL{net: net}
+	d.blockModes = blockModes{d}
+	return d, nil
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (d *DEAL) BlockSize() int {
+	return DEALBlockSize
+}
+
+// encryptBlock encrypts one block from src into dst, which may overlap.
+// DEAL applies F to the left half and feeds it into the right one, the
+// generic network works the other way round, so the halves are exchanged on
+// the way in. The network's final swap then yields DEAL's output order.
+func (d *DEAL) encryptBlock(dst, src []byte) {
+	var buf [DEALBlockSize]byte
+	copy(buf[:8], src[8:16])
+	copy(buf[8:], src[:8])
+	d.net.encryptBlock(dst, buf[:])
+}
+
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (d *DEAL) decryptBlock(dst, src []byte) {
+	var buf [DEALBlockSize]byte
+	d.net.decryptBlock(buf[:], src)
+	copy(dst[:8], buf[8:])
+	copy(dst[8:16], buf[:8])
+}
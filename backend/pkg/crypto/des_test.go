@@ -0,0 +1,108 @@
+package crypto_test
+
+import (
+	"bytes"
+	"crypto/des"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+func TestDESKnownAnswers(t *testing.T) {
+	checkVectors(t, "des.txt")
+}
+
+// TestTripleDESMatchesStdlib checks 3DES against crypto/des, expanding a
+// two-key K1 K2 to K1 K2 K1
+func TestTripleDESMatchesStdlib(t *testing.T) {
+	for _, n := range []int{16, 24} {
+		key := testKey(n)
+		c, err := crypto.NewTripleDES(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := des.NewTripleDESCipher(append(key, key[:24-n]...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pt := testMessage(crypto.DESBlockSize)
+		want := make([]byte, crypto.DESBlockSize)
+		ref.Encrypt(want, pt)
+		got, err := c.Encrypt(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%d-byte key: got %X, want %X", n, got, want)
+		}
+	}
+}
+
+// dealReference is DEAL as described in L. R. Knudsen, "DEAL - A 128-bit
+// Block Cipher", 1998, built on crypto/des: x_j = DES_RK_j(x_{j-1}) xor
+// y_{j-1}, y_j = x_{j-1}, with the round keys RK_j = DES_K(K_j xor <i> xor
+// RK_{j-1}) under the fixed key K = 0123456789ABCDEF. <i> sets bit i,
+// counted from 1 at the most significant end.
+func dealReference(t *testing.T, key, pt []byte) []byte {
+	t.Helper()
+
+	fixed, err := des.NewCipher([]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := len(key) / 8
+	rounds := 6
+	if s == 4 {
+		rounds = 8
+	}
+
+	rk := make([]byte, 8)
+	x := append([]byte(nil), pt[:8]...)
+	y := append([]byte(nil), pt[8:]...)
+	for j := 0; j < rounds; j++ {
+		block := append([]byte(nil), key[8*(j%s):8*(j%s)+8]...)
+		if j >= s {
+			i := 1 << (j - s)
+			block[(i-1)/8] ^= 0x80 >> ((i - 1) % 8)
+		}
+		for b := range block {
+			block[b] ^= rk[b]
+		}
+		fixed.Encrypt(rk, block)
+
+		round, err := des.NewCipher(rk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := make([]byte, 8)
+		round.Encrypt(next, x)
+		for b := range next {
+			next[b] ^= y[b]
+		}
+		x, y = next, x
+	}
+	return append(x, y...)
+}
+
+func TestDEALMatchesReference(t *testing.T) {
+	for _, n := range []int{16, 24, 32} {
+		for _, key := range [][]byte{make([]byte, n), testKey(n)} {
+			c, err := crypto.NewDEAL(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pt := testMessage(crypto.DEALBlockSize)
+			want := dealReference(t, key, pt)
+			got, err := c.Encrypt(pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("key %X: got %X, want %X", key, got, want)
+			}
+		}
+	}
+}
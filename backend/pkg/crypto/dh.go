@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DHGroup is a Diffie-Hellman group: the integers modulo the safe prime P
+// with generator G
+type DHGroup struct {
+	P *big.Int
+	G *big.Int
+}
+
+// DHGroup14 is the 2048-bit MODP group from RFC 3526
+var DHGroup14 = &DHGroup{
+	P: mustHex("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1" +
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D" +
+		"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9" +
+		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+		"15728E5A8AACAA68FFFFFFFFFFFFFFFF"),
+	G: big.NewInt(2),
+}
+
+func mustHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("crypto: invalid hex constant")
+	}
+	return n
+}
+
+// DHKey is one side's ephemeral Diffie-Hellman key pair
+type DHKey struct {
+	group   *DHGroup
+	private *big.Int
+	public  *big.Int
+}
+
+// GenerateDHKey creates a random key pair in the group
+func GenerateDHKey(group *DHGroup) (*DHKey, error) {
+	// The private exponent is uniform in [2, P-2]
+	limit := new(big.Int).Sub(group.P, big.NewInt(3))
+	x, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, err
+	}
+	x.Add(x, big.NewInt(2))
+
+	return &DHKey{
+		group:   group,
+		private: x,
+		public:  new(big.Int).Exp(group.G, x, group.P),
+	}, nil
+}
+
+// Public returns the public value G^x mod P as a big-endian byte string
+// of the modulus length
+func (k *DHKey) Public() []byte {
+	return k.public.FillBytes(make([]byte, k.group.size()))
+}
+
+// SharedSecret computes the shared secret from the peer's public value.
+// Values outside [2, P-2] are rejected, as they would force the secret
+// into a trivial subgroup.
+func (k *DHKey) SharedSecret(peerPublic []byte) ([]byte, error) {
+	y := new(big.Int).SetBytes(peerPublic)
+	pMinus1 := new(big.Int).Sub(k.group.P, big.NewInt(1))
+	if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(pMinus1) >= 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	z := new(big.Int).Exp(y, k.private, k.group.P)
+	return z.FillBytes(make([]byte, k.group.size())), nil
+}
+
+// size returns the length of the modulus in bytes
+func (g *DHGroup) size() int {
+	return (g.P.BitLen() + 7) / 8
+}
+
+// Transcript hashes the two public values of a handshake. They are sorted
+// first, so both participants get the same result whichever of them
+// started the exchange.
+func Transcript(public1, public2 []byte) []byte {
+	if bytes.Compare(public1, public2) > 0 {
+		public1, public2 = public2, public1
+	}
+
+	h := sha256.New()
+	for _, public := range [][]byte{public1, public2} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(public)))
+		h.Write(length[:])
+		h.Write(public)
+	}
+	return h.Sum(nil)
+}
+
+// DeriveSessionKey derives the algorithm's key with HKDF-SHA256 from the
+// shared secret and a PBKDF2 hash of the room password, using the room salt
+// and the algorithm name followed by the Transcript as context
+func DeriveSessionKey(algorithm string, secret, password, salt, transcript []byte) ([]byte, error) {
+	size, err := KeySize(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	passwordKey, err := stretch(password, salt, sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	ikm := append(append([]byte(nil), secret...), passwordKey...)
+	info := append([]byte(algorithm), transcript...)
+
+	key := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
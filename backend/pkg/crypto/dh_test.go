@@ -0,0 +1,94 @@
+package crypto_test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+// handshake runs a full exchange between two parties holding the given
+// passwords and returns their session keys
+func handshake(t *testing.T, password1, password2 string) ([]byte, []byte) {
+	t.Helper()
+
+	a, err := crypto.GenerateDHKey(crypto.DHGroup14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := crypto.GenerateDHKey(crypto.DHGroup14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	salt := testKey(crypto.SaltSize)
+	session := func(k *crypto.DHKey, peer []byte, password string) []byte {
+		secret, err := k.SharedSecret(peer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := crypto.DeriveSessionKey("AES", secret, []byte(password), salt, crypto.Transcript(k.Public(), peer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	return session(a, b.Public(), password1), session(b, a.Public(), password2)
+}
+
+func TestDHSessionKey(t *testing.T) {
+	keyA, keyB := handshake(t, "room password", "room password")
+	if !bytes.Equal(keyA, keyB) {
+		t.Fatalf("session keys differ: %X, %X", keyA, keyB)
+	}
+	if len(keyA) != 32 {
+		t.Errorf("%d-byte AES key, want 32", len(keyA))
+	}
+
+	// A relay that does not know the password cannot match either side
+	keyA, keyB = handshake(t, "room password", "guess")
+	if bytes.Equal(keyA, keyB) {
+		t.Error("different passwords gave the same session key")
+	}
+}
+
+func TestTranscriptOrder(t *testing.T) {
+	a, b := testKey(256), testMessage(256)
+	if !bytes.Equal(crypto.Transcript(a, b), crypto.Transcript(b, a)) {
+		t.Error("Transcript depends on the order of its arguments")
+	}
+	// The lengths are hashed too, so moving bytes between the values matters
+	if bytes.Equal(crypto.Transcript([]byte{1}, []byte{2, 3}), crypto.Transcript([]byte{1, 2}, []byte{3})) {
+		t.Error("Transcript is ambiguous")
+	}
+}
+
+func TestSharedSecretRejects(t *testing.T) {
+	k, err := crypto.GenerateDHKey(crypto.DHGroup14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := crypto.DHGroup14.P
+	for name, y := range map[string]*big.Int{
+		"0":   big.NewInt(0),
+		"1":   big.NewInt(1),
+		"p-1": new(big.Int).Sub(p, big.NewInt(1)),
+		"p":   p,
+		"p+2": new(big.Int).Add(p, big.NewInt(2)),
+		"2p":  new(big.Int).Lsh(p, 1),
+	} {
+		if _, err := k.SharedSecret(y.Bytes()); !errors.Is(err, crypto.ErrInvalidPublicKey) {
+			t.Errorf("%s: err = %v, want ErrInvalidPublicKey", name, err)
+		}
+	}
+
+	if _, err := k.SharedSecret(nil); !errors.Is(err, crypto.ErrInvalidPublicKey) {
+		t.Errorf("empty: err = %v", err)
+	}
+	if _, err := k.SharedSecret([]byte{2}); err != nil {
+		t.Errorf("2: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+// Package elgamal implements ElGamal encryption and signatures in the
+// multiplicative group modulo a safe prime, on top of math/big.
+//
+// The group is generated by a primitive root g of p = 2q + 1. Signatures
+// are the original ElGamal scheme over a SHA-256 digest.
+package elgamal
+
+import (
+	"CryptographyCW/pkg/crypto/primes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrGroupSize      = errors.New("elgamal: group must be at least 256 bits")
+	ErrMessageTooLong = errors.New("elgamal: message too long for the group size")
+	ErrDecryption     = errors.New("elgamal: decryption error")
+	ErrVerification   = errors.New("elgamal: verification error")
+	ErrInvalidKey     = errors.New("elgamal: invalid key")
+)
+
+var (
+	one = big.NewInt(1)
+	two = big.NewInt(2)
+)
+
+// Parameters describe the group shared by a set of keys
+type Parameters struct {
+	P *big.Int // safe prime modulus
+	G *big.Int // primitive root mod P
+}
+
+// PublicKey is an ElGamal public key
+type PublicKey struct {
+	Parameters
+	Y *big.Int // G^X mod P
+}
+
+// PrivateKey is an ElGamal private key
+type PrivateKey struct {
+	PublicKey
+	X *big.Int // secret exponent in [1, P-2]
+}
+
+// GenerateParameters creates a group modulo a random safe prime of the given
+// number of bits. Primes are accepted by test with at least minProbability;
+// a nil test means primes.MillerRabin and a zero probability means
+// primes.DefaultProbability.
+func GenerateParameters(bits int, test primes.PrimalityTest, minProbability float64) (*Parameters, error) {
+	if bits < 256 {
+		return nil, ErrGroupSize
+	}
+	if test == nil {
+		test = primes.MillerRabin{}
+	}
+	if minProbability == 0 {
+		minProbability = primes.DefaultProbability
+	}
+
+	p, q, err := primes.Safe(bits, test, minProbability)
+	if err != nil {
+		return nil, err
+	}
+
+	// The group has order 2q, so g is a primitive root unless g^2 = 1 or g^q = 1
+	pMinus1 := new(big.Int).Sub(p, one)
+	for {
+		g, err := randomInRange(two, pMinus1)
+		if err != nil {
+			return nil, err
+		}
+		if new(big.Int).Exp(g, q, p).Cmp(one) != 0 {
+			return &Parameters{P: p, G: g}, nil
+		}
+	}
+}
+
+// GenerateKey creates a key pair in the group
+func GenerateKey(params *Parameters) (*PrivateKey, error) {
+	x, err := randomInRange(one, new(big.Int).Sub(params.P, one))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrivateKey{
+		PublicKey: PublicKey{
+			Parameters: *params,
+			Y:          new(big.Int).Exp(params.G, x, params.P),
+		},
+		X: x,
+	}, nil
+}
+
+// randomInRange returns a uniform random integer in [lo, hi)
+func randomInRange(lo, hi *big.Int) (*big.Int, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Sub(hi, lo))
+	if err != nil {
+		return nil, err
+	}
+	return n.Add(n, lo), nil
+}
+
+// Size returns the modulus length in bytes
+func (pub *PublicKey) Size() int {
+	return (pub.P.BitLen() + 7) / 8
+}
+
+// Components returns the public key as named big-endian integers, the
+// form in which it travels in public_key messages
+func (pub *PublicKey) Components() map[string][]byte {
+	return map[string][]byte{
+		"p": pub.P.Bytes(),
+		"g": pub.G.Bytes(),
+		"y": pub.Y.Bytes(),
+	}
+}
+
+// ParsePublicKey rebuilds a public key from Components
+func ParsePublicKey(c map[string][]byte) (*PublicKey, error) {
+	p, g, y := c["p"], c["g"], c["y"]
+	if len(p) == 0 || len(g) == 0 || len(y) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	pub := &PublicKey{
+		Parameters: Parameters{P: new(big.Int).SetBytes(p), G: new(big.Int).SetBytes(g)},
+		Y:          new(big.Int).SetBytes(y),
+	}
+	pMinus1 := new(big.Int).Sub(pub.P, one)
+	if pub.P.BitLen() < 256 || pub.P.Bit(0) == 0 ||
+		pub.G.Cmp(one) <= 0 || pub.G.Cmp(pMinus1) >= 0 ||
+		pub.Y.Cmp(one) <= 0 || pub.Y.Cmp(pMinus1) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	return pub, nil
+}
+
+// Encrypt encrypts msg, which can be at most Size() - 2 bytes long. The
+// ciphertext is (g^k, m y^k) for a random k, each half Size() bytes.
+func Encrypt(pub *PublicKey, msg []byte) ([]byte, error) {
+	k := pub.Size()
+	if len(msg) > k-2 {
+		return nil, ErrMessageTooLong
+	}
+
+	// A leading 0x01 keeps leading zero bytes of msg and keeps m nonzero
+	m := new(big.Int).SetBytes(append([]byte{0x01}, msg...))
+
+	eph, err := randomInRange(one, new(big.Int).Sub(pub.P, one))
+	if err != nil {
+		return nil, err
+	}
+	c1 := new(big.Int).Exp(pub.G, eph, pub.P)
+	c2 := new(big.Int).Exp(pub.Y, eph, pub.P)
+	c2.Mul(c2, m)
+	c2.Mod(c2, pub.P)
+
+	out := make([]byte, 2*k)
+	c1.FillBytes(out[:k])
+	c2.FillBytes(out[k:])
+	return out, nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt
+func Decrypt(priv *PrivateKey, ciphertext []byte) ([]byte, error) {
+	k := priv.Size()
+	if len(ciphertext) != 2*k {
+		return nil, ErrDecryption
+	}
+
+	c1 := new(big.Int).SetBytes(ciphertext[:k])
+	c2 := new(big.Int).SetBytes(ciphertext[k:])
+	if c1.Sign() == 0 || c1.Cmp(priv.P) >= 0 || c2.Cmp(priv.P) >= 0 {
+		return nil, ErrDecryption
+	}
+
+	// m = c2 / c1^x = c2 c1^(p-1-x)
+	e := new(big.Int).Sub(priv.P, one)
+	e.Sub(e, priv.X)
+	m := new(big.Int).Exp(c1, e, priv.P)
+	m.Mul(m, c2)
+	m.Mod(m, priv.P)
+
+	em := m.Bytes()
+	if len(em) == 0 || em[0] != 0x01 {
+		return nil, ErrDecryption
+	}
+	return em[1:], nil
+}
+
+// digest returns SHA-256(msg) as an integer mod p-1
+func digest(msg []byte, pMinus1 *big.Int) *big.Int {
+	sum := sha256.Sum256(msg)
+	h := new(big.Int).SetBytes(sum[:])
+	return h.Mod(h, pMinus1)
+}
+
+// Sign signs msg. The signature is (r, s) with r = g^k and
+// s = (H(msg) - x r) / k mod p-1, each half Size() bytes.
+func Sign(priv *PrivateKey, msg []byte) ([]byte, error) {
+	pMinus1 := new(big.Int).Sub(priv.P, one)
+	h := digest(msg, pMinus1)
+
+	for {
+		// k must be invertible mod p-1
+		k, err := randomInRange(one, pMinus1)
+		if err != nil {
+			return nil, err
+		}
+		kInv := new(big.Int).ModInverse(k, pMinus1)
+		if kInv == nil {
+			continue
+		}
+
+		r := new(big.Int).Exp(priv.G, k, priv.P)
+		s := new(big.Int).Mul(priv.X, r)
+		s.Sub(h, s)
+		s.Mul(s, kInv)
+		s.Mod(s, pMinus1)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		size := priv.Size()
+		out := make([]byte, 2*size)
+		r.FillBytes(out[:size])
+		s.FillBytes(out[size:])
+		return out, nil
+	}
+}
+
+// Verify checks a signature produced by Sign: g^H(msg) = y^r r^s mod p
+func Verify(pub *PublicKey, msg, sig []byte) error {
+	size := pub.Size()
+	if len(sig) != 2*size {
+		return ErrVerification
+	}
+
+	pMinus1 := new(big.Int).Sub(pub.P, one)
+	r := new(big.Int).SetBytes(sig[:size])
+	s := new(big.Int).SetBytes(sig[size:])
+	if r.Sign() <= 0 || r.Cmp(pub.P) >= 0 || s.Sign() <= 0 || s.Cmp(pMinus1) >= 0 {
+		return ErrVerification
+	}
+
+	lhs := new(big.Int).Exp(pub.G, digest(msg, pMinus1), pub.P)
+	rhs := new(big.Int).Exp(pub.Y, r, pub.P)
+	rhs.Mul(rhs, new(big.Int).Exp(r, s, pub.P))
+	rhs.Mod(rhs, pub.P)
+	if lhs.Cmp(rhs) != 0 {
+		return ErrVerification
+	}
+	return nil
+}
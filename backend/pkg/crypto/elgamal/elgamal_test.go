@@ -0,0 +1,178 @@
+package elgamal_test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"CryptographyCW/pkg/crypto/elgamal"
+)
+
+// newKey generates a key in a fresh 256-bit group
+func newKey(t *testing.T) *elgamal.PrivateKey {
+	t.Helper()
+	params, err := elgamal.GenerateParameters(256, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := elgamal.GenerateKey(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func TestGenerateParameters(t *testing.T) {
+	priv := newKey(t)
+	p, g := priv.P, priv.G
+	if p.BitLen() != 256 || !p.ProbablyPrime(20) {
+		t.Fatalf("p = %v is not a 256-bit prime", p)
+	}
+	q := new(big.Int).Rsh(p, 1)
+	if !q.ProbablyPrime(20) {
+		t.Fatalf("p = %v is not a safe prime", p)
+	}
+
+	// g generates the whole group of order 2q
+	one := big.NewInt(1)
+	if new(big.Int).Exp(g, big.NewInt(2), p).Cmp(one) == 0 || new(big.Int).Exp(g, q, p).Cmp(one) == 0 {
+		t.Errorf("g = %v is not a primitive root", g)
+	}
+	if new(big.Int).Exp(g, priv.X, p).Cmp(priv.Y) != 0 {
+		t.Error("y != g^x")
+	}
+
+	if _, err := elgamal.GenerateParameters(128, nil, 0); !errors.Is(err, elgamal.ErrGroupSize) {
+		t.Errorf("128 bits: err = %v", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	priv := newKey(t)
+	maxLen := priv.Size() - 2
+
+	for _, msg := range [][]byte{{}, {0}, {0, 0, 1}, []byte("hello"), bytes.Repeat([]byte{0xFF}, maxLen)} {
+		c, err := elgamal.Encrypt(&priv.PublicKey, msg)
+		if err != nil {
+			t.Fatalf("%x: %v", msg, err)
+		}
+		if len(c) != 2*priv.Size() {
+			t.Errorf("%x: %d-byte ciphertext", msg, len(c))
+		}
+		got, err := elgamal.Decrypt(priv, c)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("%x: round trip gave %x, %v", msg, got, err)
+		}
+	}
+
+	if _, err := elgamal.Encrypt(&priv.PublicKey, make([]byte, maxLen+1)); !errors.Is(err, elgamal.ErrMessageTooLong) {
+		t.Errorf("long message: err = %v", err)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	priv := newKey(t)
+	k := priv.Size()
+	c, err := elgamal.Encrypt(&priv.PublicKey, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zeroC1 := bytes.Clone(c)
+	clear(zeroC1[:k])
+	bigC1 := bytes.Clone(c)
+	priv.P.FillBytes(bigC1[:k])
+	bigC2 := bytes.Clone(c)
+	priv.P.FillBytes(bigC2[k:])
+
+	for name, ciphertext := range map[string][]byte{
+		"truncated": c[1:],
+		"extended":  append(bytes.Clone(c), 0),
+		"c1 = 0":    zeroC1,
+		"c1 = p":    bigC1,
+		"c2 = p":    bigC2,
+	} {
+		if _, err := elgamal.Decrypt(priv, ciphertext); !errors.Is(err, elgamal.ErrDecryption) {
+			t.Errorf("%s: err = %v, want ErrDecryption", name, err)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv := newKey(t)
+	k := priv.Size()
+	msg := []byte("signed message")
+
+	sig, err := elgamal.Sign(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := elgamal.Verify(&priv.PublicKey, msg, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := bytes.Clone(sig)
+	flipped[len(sig)-1] ^= 0x01
+	zeroR := bytes.Clone(sig)
+	clear(zeroR[:k])
+	bigS := bytes.Clone(sig)
+	new(big.Int).Sub(priv.P, big.NewInt(1)).FillBytes(bigS[k:])
+
+	tests := []struct {
+		name string
+		msg  []byte
+		sig  []byte
+	}{
+		{"other message", []byte("signed messagf"), sig},
+		{"bit flip", msg, flipped},
+		{"truncated", msg, sig[1:]},
+		{"r = 0", msg, zeroR},
+		{"s = p-1", msg, bigS},
+	}
+	for _, tt := range tests {
+		if err := elgamal.Verify(&priv.PublicKey, tt.msg, tt.sig); !errors.Is(err, elgamal.ErrVerification) {
+			t.Errorf("%s: err = %v, want ErrVerification", tt.name, err)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub := &newKey(t).PublicKey
+	got, err := elgamal.ParsePublicKey(pub.Components())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.P.Cmp(pub.P) != 0 || got.G.Cmp(pub.G) != 0 || got.Y.Cmp(pub.Y) != 0 {
+		t.Error("Components round trip changed the key")
+	}
+
+	// with returns the components with one of them replaced
+	with := func(name string, value *big.Int) map[string][]byte {
+		c := pub.Components()
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value.Bytes()
+		}
+		return c
+	}
+	pMinus1 := new(big.Int).Sub(pub.P, big.NewInt(1))
+	tests := map[string]map[string][]byte{
+		"empty":     {},
+		"no p":      with("p", nil),
+		"no y":      with("y", nil),
+		"p even":    with("p", new(big.Int).Add(pub.P, big.NewInt(1))),
+		"p small":   with("p", big.NewInt(1000003)),
+		"g = 1":     with("g", big.NewInt(1)),
+		"g = p-1":   with("g", pMinus1),
+		"y = 1":     with("y", big.NewInt(1)),
+		"y = p-1":   with("y", pMinus1),
+		"y above p": with("y", new(big.Int).Lsh(pub.P, 1)),
+	}
+	for name, c := range tests {
+		if _, err := elgamal.ParsePublicKey(c); !errors.Is(err, elgamal.ErrInvalidKey) {
+			t.Errorf("%s: err = %v, want ErrInvalidKey", name, err)
+		}
+	}
+}
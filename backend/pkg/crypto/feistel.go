@@ -0,0 +1,97 @@
+package crypto
+
+import "errors"
+
+// maxFeistelHalf bounds the half-block size so round buffers fit on the stack
+const maxFeistelHalf = 32
+
+// RoundFunction is the F function of a Feistel network
+type RoundFunction interface {
+	// Apply writes F(half, roundKey) to dst. dst and half have the same
+	// length and do not overlap.
+	Apply(dst, half, roundKey []byte)
+}
+
+// KeySchedule expands a cipher key into round keys
+type KeySchedule interface {
+	// RoundKeys returns one key per round, in encryption order
+	RoundKeys(key []byte) ([][]byte, error)
+}
+
+// Feistel is a generic balanced Feistel network. Each round maps (L, R) to
+// (R, L xor F(R, K_i)); the halves are swapped back after the last round, so
+// decryption is the same network with the round keys in reverse order.
+type Feistel struct {
+	blockModes
+	blockSize int
+	f         RoundFunction
+	roundKeys [][]byte
+}
+
+// NewFeistel creates a Feistel network with the given block size in bytes,
+// using schedule to expand key and f as the round function
+func NewFeistel(blockSize int, schedule KeySchedule, f RoundFunction, key []byte) (*Feistel, error) {
+	if blockSize <= 0 || blockSize%2 != 0 || blockSize > 2*maxFeistelHalf {
+		return nil, errors.New("feistel: block size must be even and at most 64 bytes")
+	}
+
+	roundKeys, err := schedule.RoundKeys(key)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Feistel{
+		blockSize: blockSize,
+		f:         f,
+		roundKeys: roundKeys,
+	}
+	n.blockModes = blockModes{n}
+	return n, nil
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (n *Feistel) BlockSize() int {
+	return n.blockSize
+}
+
+// Rounds returns the number of rounds
+func (n *Feistel) Rounds() int {
+	return len(n.roundKeys)
+}
+
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (n *Feistel) encryptBlock(dst, src []byte) {
+	n.crypt(dst, src, false)
+}
+
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (n *Feistel) decryptBlock(dst, src []byte) {
+	n.crypt(dst, src, true)
+}
+
+// crypt runs the rounds over one block, with the round keys reversed when decrypting
+func (n *Feistel) crypt(dst, src []byte, reverse bool) {
+	var buf [3 * maxFeistelHalf]byte
+	h := n.blockSize / 2
+	L, R, F := buf[:h], buf[h:2*h], buf[2*h:3*h]
+	copy(L, src[:h])
+	copy(R, src[h:n.blockSize])
+
+	rounds := len(n.roundKeys)
+	for i := 0; i < rounds; i++ {
+		k := n.roundKeys[i]
+		if reverse {
+			k = n.roundKeys[rounds-1-i]
+		}
+
+		n.f.Apply(F, R, k)
+		for j := range F {
+			L[j] ^= F[j]
+		}
+		L, R = R, L
+	}
+
+	// Undo the last swap
+	copy(dst[:h], R)
+	copy(dst[h:n.blockSize], L)
+}
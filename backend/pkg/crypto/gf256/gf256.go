@@ -0,0 +1,108 @@
+// Package gf256 implements arithmetic in GF(2^8) for a chosen modulus.
+//
+// Field elements are bytes whose bits are the coefficients of a polynomial
+// over GF(2). A modulus is a polynomial of degree 8 written with its x^8
+// term, e.g. 0x11B for x^8 + x^4 + x^3 + x + 1, the AES modulus.
+package gf256
+
+import "errors"
+
+// AES is the modulus used by AES and Rijndael, x^8 + x^4 + x^3 + x + 1
+const AES uint16 = 0x11B
+
+var (
+	ErrNotIrreducible = errors.New("gf256: modulus is not an irreducible polynomial of degree 8")
+	ErrZeroInverse    = errors.New("gf256: zero has no inverse")
+)
+
+// Add returns a + b, which is also a - b
+func Add(a, b byte) byte {
+	return a ^ b
+}
+
+// Mul returns a * b modulo poly
+func Mul(a, b byte, poly uint16) byte {
+	x, y := uint16(a), b
+	var result uint16
+	for y != 0 {
+		if y&1 != 0 {
+			result ^= x
+		}
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= poly
+		}
+		y >>= 1
+	}
+	return byte(result)
+}
+
+// Pow returns a^n modulo poly
+func Pow(a byte, n int, poly uint16) byte {
+	result := byte(1)
+	for ; n > 0; n >>= 1 {
+		if n&1 != 0 {
+			result = Mul(result, a, poly)
+		}
+		a = Mul(a, a, poly)
+	}
+	return result
+}
+
+// Inverse returns the multiplicative inverse of a modulo poly.
+// The modulus must be irreducible and a must not be zero.
+func Inverse(a byte, poly uint16) (byte, error) {
+	if !IsIrreducible(poly) {
+		return 0, ErrNotIrreducible
+	}
+	if a == 0 {
+		return 0, ErrZeroInverse
+	}
+
+	// The multiplicative group has order 255, so a^254 = a^-1
+	return Pow(a, 254, poly), nil
+}
+
+// IsIrreducible reports whether poly is an irreducible polynomial of degree 8
+func IsIrreducible(poly uint16) bool {
+	if poly>>8 != 1 {
+		return false
+	}
+
+	// A reducible polynomial of degree 8 has a factor of degree at most 4
+	for d := uint16(2); d < 0x20; d++ {
+		if polyMod(poly, d) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Irreducible lists every irreducible polynomial of degree 8 in ascending order
+func Irreducible() []uint16 {
+	var polys []uint16
+	for p := uint16(0x100); p < 0x200; p++ {
+		if IsIrreducible(p) {
+			polys = append(polys, p)
+		}
+	}
+	return polys
+}
+
+// polyMod returns the remainder of a divided by b as polynomials over GF(2)
+func polyMod(a, b uint16) uint16 {
+	db := degree(b)
+	for da := degree(a); da >= db; da = degree(a) {
+		a ^= b << (da - db)
+	}
+	return a
+}
+
+// degree returns the degree of p, or -1 for the zero polynomial
+func degree(p uint16) int {
+	d := -1
+	for ; p != 0; p >>= 1 {
+		d++
+	}
+	return d
+}
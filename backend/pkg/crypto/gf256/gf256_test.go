@@ -0,0 +1,67 @@
+package gf256_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"CryptographyCW/pkg/crypto/gf256"
+)
+
+func TestIrreducible(t *testing.T) {
+	polys := gf256.Irreducible()
+	if len(polys) != 30 {
+		t.Errorf("%d irreducible polynomials, want 30", len(polys))
+	}
+	if !slices.Contains(polys, gf256.AES) {
+		t.Error("AES modulus 0x11B missing")
+	}
+
+	// x^8 and x^8 + 1 = (x + 1)^8 factor; 0x1B and 0x21B are not degree 8
+	for _, p := range []uint16{0x100, 0x101, 0x1B, 0x21B} {
+		if gf256.IsIrreducible(p) {
+			t.Errorf("%#x reported irreducible", p)
+		}
+	}
+}
+
+// TestMulFIPS197 checks the multiplication examples of FIPS-197, section 4.2
+func TestMulFIPS197(t *testing.T) {
+	tests := []struct{ a, b, want byte }{
+		{0x57, 0x83, 0xC1},
+		{0x57, 0x02, 0xAE},
+		{0x57, 0x04, 0x47},
+		{0x57, 0x08, 0x8E},
+		{0x57, 0x10, 0x07},
+		{0x57, 0x13, 0xFE},
+	}
+	for _, tt := range tests {
+		if got := gf256.Mul(tt.a, tt.b, gf256.AES); got != tt.want {
+			t.Errorf("%02X * %02X = %02X, want %02X", tt.a, tt.b, got, tt.want)
+		}
+		if got := gf256.Mul(tt.b, tt.a, gf256.AES); got != tt.want {
+			t.Errorf("%02X * %02X = %02X, want %02X", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestInverse(t *testing.T) {
+	for _, poly := range gf256.Irreducible() {
+		for a := 1; a < 256; a++ {
+			inv, err := gf256.Inverse(byte(a), poly)
+			if err != nil {
+				t.Fatalf("%#x: Inverse(%02X): %v", poly, a, err)
+			}
+			if got := gf256.Mul(byte(a), inv, poly); got != 1 {
+				t.Fatalf("%#x: %02X * %02X = %02X", poly, a, inv, got)
+			}
+		}
+	}
+
+	if _, err := gf256.Inverse(0, gf256.AES); !errors.Is(err, gf256.ErrZeroInverse) {
+		t.Errorf("Inverse(0): err = %v", err)
+	}
+	if _, err := gf256.Inverse(2, 0x101); !errors.Is(err, gf256.ErrNotIrreducible) {
+		t.Errorf("reducible modulus: err = %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package kat runs known-answer tests against the ciphers in pkg/crypto.
+//
+// Vector files are plain text in the style of the NIST and NESSIE
+// response files: "NAME = value" lines, with vectors separated by blank
+// lines and "#" starting a comment. ALGORITHM, MODE and PADDING set the
+// defaults for the vectors that follow; inside a vector they apply to that
+// vector only.
+//
+//	# RC5-32/12/16, from the RC5 paper
+//	ALGORITHM = RC5-32/12
+//
+//	COUNT = 0
+//	KEY = 00000000000000000000000000000000
+//	PT = 0000000000000000
+//	CT = 21A5DBEE154B8F6D
+//
+// KEY, PT and CT are required and hex encoded, as is IV; spaces inside a
+// value are ignored. Without a MODE,
+// PT is a single block checked with Encrypt and Decrypt. With one, PT is
+// the whole message passed to EncryptWithMode, PADDING defaults to Zeros
+// and CT includes any padding.
+package kat
+
+import (
+	"CryptographyCW/pkg/crypto"
+	"bufio"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// testdata holds the vectors shipped with the package
+//
+//go:embed testdata/*.txt
+var testdata embed.FS
+
+// Vector is a single known-answer test
+type Vector struct {
+	File       string // file and line the vector starts on, for reports
+	Line       int
+	Count      int
+	Algorithm  string
+	Mode       string // empty for a single-block test
+	Padding    crypto.PaddingType
+	Key        []byte
+	IV         []byte
+	Plaintext  []byte
+	Ciphertext []byte
+}
+
+// String identifies the vector in reports
+func (v Vector) String() string {
+	name := v.Algorithm
+	if v.Mode != "" {
+		name += " " + v.Mode + "/" + string(v.Padding)
+	}
+	return fmt.Sprintf("%s:%d: %s COUNT = %d", v.File, v.Line, name, v.Count)
+}
+
+// Parse reads the vectors in r. name is used in error messages and reports.
+func Parse(r io.Reader, name string) ([]Vector, error) {
+	var (
+		vectors  []Vector
+		defaults Vector
+		cur      *Vector
+		lineNo   int
+	)
+	defaults.File = name
+	defaults.Padding = crypto.Zeros
+
+	finish := func() error {
+		if cur == nil {
+			return nil
+		}
+		v := *cur
+		cur = nil
+		switch {
+		case v.Algorithm == "":
+			return fmt.Errorf("%s:%d: vector has no ALGORITHM", name, v.Line)
+		case v.Key == nil || v.Plaintext == nil || v.Ciphertext == nil:
+			return fmt.Errorf("%s:%d: vector needs KEY, PT and CT", name, v.Line)
+		}
+		vectors = append(vectors, v)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		field, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected NAME = value", name, lineNo)
+		}
+		field = strings.ToUpper(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		// Settings outside a vector become defaults for the following ones
+		target := cur
+		switch field {
+		case "ALGORITHM", "MODE", "PADDING":
+			if target == nil {
+				target = &defaults
+			}
+		default:
+			if cur == nil {
+				v := defaults
+				v.Line = lineNo
+				cur = &v
+			}
+			target = cur
+		}
+
+		var err error
+		switch field {
+		case "ALGORITHM":
+			target.Algorithm = value
+		case "MODE":
+			target.Mode = value
+		case "PADDING":
+			target.Padding = crypto.PaddingType(value)
+		case "COUNT":
+			target.Count, err = strconv.Atoi(value)
+		case "KEY":
+			target.Key, err = decodeHex(value)
+		case "IV":
+			target.IV, err = decodeHex(value)
+		case "PT", "PLAINTEXT":
+			target.Plaintext, err = decodeHex(value)
+		case "CT", "CIPHERTEXT":
+			target.Ciphertext, err = decodeHex(value)
+		default:
+			err = fmt.Errorf("unknown field %s", field)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// decodeHex decodes a hex value, which may be split into groups by spaces
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+}
+
+// Load reads every *.txt file at the root of fsys, in name order
+func Load(fsys fs.FS) ([]Vector, error) {
+	names, err := fs.Glob(fsys, "*.txt")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var vectors []Vector
+	for _, name := range names {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		vs, err := Parse(f, path.Base(name))
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vs...)
+	}
+	return vectors, nil
+}
+
+// LoadDir reads every *.txt file in a directory
+func LoadDir(dir string) ([]Vector, error) {
+	return Load(os.DirFS(dir))
+}
+
+// Builtin returns the vectors shipped with the package
+func Builtin() ([]Vector, error) {
+	sub, err := fs.Sub(testdata, "testdata")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
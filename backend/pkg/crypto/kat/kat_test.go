@@ -0,0 +1,11 @@
+package kat_test
+
+import (
+	"testing"
+
+	"CryptographyCW/pkg/crypto/kat"
+)
+
+func TestKnownAnswers(t *testing.T) {
+	kat.Check(t)
+}
@@ -0,0 +1,165 @@
+package kat
+
+import (
+	"CryptographyCW/pkg/crypto"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+)
+
+// Run checks one vector in both directions
+func Run(v Vector) error {
+	c, err := crypto.NewCipher(v.Algorithm, v.Key)
+	if err != nil {
+		return fmt.Errorf("%v: %w", v, err)
+	}
+
+	var ct, pt []byte
+	if v.Mode == "" {
+		if ct, err = c.Encrypt(v.Plaintext); err == nil {
+			pt, err = c.Decrypt(v.Ciphertext)
+		}
+	} else {
+		if ct, err = c.EncryptWithMode(v.Plaintext, v.IV, v.Mode, v.Padding); err == nil {
+			pt, err = c.DecryptWithMode(v.Ciphertext, v.IV, v.Mode, v.Padding)
+		}
+		// ISO 10126 padding is random, so only decryption is deterministic
+		if v.Padding == crypto.ISO10126 {
+			ct = v.Ciphertext
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %w", v, err)
+	}
+
+	if !bytes.Equal(ct, v.Ciphertext) {
+		return fmt.Errorf("%v: encrypt got %X, want %X", v, ct, v.Ciphertext)
+	}
+	if !bytes.Equal(pt, v.Plaintext) {
+		return fmt.Errorf("%v: decrypt got %X, want %X", v, pt, v.Plaintext)
+	}
+	return nil
+}
+
+// RoundTrip encrypts and decrypts random messages of several lengths with
+// a random key and IV, using the given algorithm, mode and padding
+func RoundTrip(algorithm, mode string, padding crypto.PaddingType) error {
+	size, err := crypto.KeySize(algorithm)
+	if err != nil {
+		return fmt.Errorf("%s: %w", algorithm, err)
+	}
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	c, err := crypto.NewCipher(algorithm, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", algorithm, err)
+	}
+
+	bs := c.BlockSize()
+	iv := make([]byte, bs)
+	if _, err := rand.Read(iv); err != nil {
+		return err
+	}
+
+	for _, n := range []int{0, 1, bs - 1, bs, 3*bs + 5} {
+		msg := make([]byte, n)
+		if _, err := rand.Read(msg); err != nil {
+			return err
+		}
+		// Zero padding cannot tell trailing zeros from padding
+		if padding == crypto.Zeros && n > 0 && msg[n-1] == 0 {
+			msg[n-1] = 1
+		}
+
+		ct, err := c.EncryptWithMode(msg, iv, mode, padding)
+		if err != nil {
+			return fmt.Errorf("%s %s/%s, %d bytes: encrypt: %w", algorithm, mode, padding, n, err)
+		}
+		pt, err := c.DecryptWithMode(ct, iv, mode, padding)
+		if err != nil {
+			return fmt.Errorf("%s %s/%s, %d bytes: decrypt: %w", algorithm, mode, padding, n, err)
+		}
+		if !bytes.Equal(pt, msg) {
+			return fmt.Errorf("%s %s/%s, %d bytes: round trip mismatch", algorithm, mode, padding, n)
+		}
+	}
+	return nil
+}
+
+// Report is the outcome of SelfTest
+type Report struct {
+	Vectors    int      // known-answer tests run
+	RoundTrips int      // algorithm, mode and padding combinations checked
+	Failures   []error  // every failed check
+	Untested   []string // algorithms without a known-answer test
+}
+
+// OK reports whether every check passed
+func (r *Report) OK() bool {
+	return len(r.Failures) == 0
+}
+
+// SelfTest runs the vectors, then the round-trip sweep over every
+// registered algorithm, mode and padding
+func SelfTest(vectors []Vector) *Report {
+	r := &Report{}
+
+	covered := make(map[string]bool)
+	for _, v := range vectors {
+		r.Vectors++
+		if err := Run(v); err != nil {
+			r.Failures = append(r.Failures, err)
+		}
+		covered[family(v.Algorithm)] = true
+	}
+
+	for _, a := range crypto.Algorithms() {
+		alg := a.Name
+		if !covered[family(alg)] {
+			r.Untested = append(r.Untested, alg)
+		}
+		for _, mode := range crypto.Modes {
+			for _, padding := range crypto.Paddings {
+				r.RoundTrips++
+				if err := RoundTrip(alg, mode, padding); err != nil {
+					r.Failures = append(r.Failures, err)
+				}
+			}
+		}
+	}
+	return r
+}
+
+// family strips the variant from an algorithm name, "RC5-64/16" -> "RC5"
+func family(algorithm string) string {
+	for i, ch := range algorithm {
+		if ch == '-' || ch == '/' {
+			return algorithm[:i]
+		}
+	}
+	return algorithm
+}
+
+// Reporter is the part of testing.TB used by Check
+type Reporter interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Check runs SelfTest on the built-in vectors and reports every failure
+// to t, so a test can simply call kat.Check(t)
+func Check(t Reporter) {
+	t.Helper()
+
+	vectors, err := Builtin()
+	if err != nil {
+		t.Errorf("kat: %v", err)
+		return
+	}
+	for _, err := range SelfTest(vectors).Failures {
+		t.Errorf("%v", err)
+	}
+}
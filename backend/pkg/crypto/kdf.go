@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Parameters for password-based key derivation
+const (
+	// SaltSize is the length of the per-room salt in bytes
+	SaltSize = 16
+	// KDFIterations is the PBKDF2-HMAC-SHA256 iteration count
+	KDFIterations = 100_000
+)
+
+// NewSalt returns a random room salt for DeriveSessionKey
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// KeySize returns the length in bytes of session keys for the algorithm
+func KeySize(algorithm string) (int, error) {
+	a, ok := LookupAlgorithm(algorithm)
+	if !ok {
+		return 0, ErrUnsupportedAlgorithm
+	}
+	return a.KeySize, nil
+}
+
+// stretch runs PBKDF2-HMAC-SHA256 over the password, producing size bytes
+func stretch(password, salt []byte, size int) ([]byte, error) {
+	if len(salt) < 8 {
+		return nil, ErrInvalidSalt
+	}
+	return pbkdf2.Key(password, salt, KDFIterations, size, sha256.New), nil
+}
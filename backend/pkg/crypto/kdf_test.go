@@ -0,0 +1,87 @@
+package crypto_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDeriveSessionKey(t *testing.T) {
+	secret, password, salt := testKey(32), []byte("correct horse"), testKey(crypto.SaltSize)
+	transcript := testMessage(32)
+
+	// HKDF-SHA256 over secret || PBKDF2-HMAC-SHA256(password, salt, 100000)
+	// with the salt and "AES" || transcript as context, computed separately
+	// with Python's hashlib and hmac
+	want := mustHex(t, "705737982dcf0a9f00f2d3ead5d0e8068f3d8f3914fcee87c27736a57213eeff")
+
+	base, err := crypto.DeriveSessionKey("AES", secret, password, salt, transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(base, want) {
+		t.Fatalf("got %x, want %x", base, want)
+	}
+	again, err := crypto.DeriveSessionKey("AES", secret, password, salt, transcript)
+	if err != nil || !bytes.Equal(again, base) {
+		t.Errorf("second derivation gave %x, %v", again, err)
+	}
+
+	for name, args := range map[string]struct {
+		algorithm                          string
+		secret, password, salt, transcript []byte
+	}{
+		"secret":     {"AES", testMessage(32), password, salt, transcript},
+		"password":   {"AES", secret, []byte("correct horsf"), salt, transcript},
+		"salt":       {"AES", secret, password, testMessage(crypto.SaltSize), transcript},
+		"algorithm":  {"Serpent", secret, password, salt, transcript},
+		"transcript": {"AES", secret, password, salt, testKey(32)},
+	} {
+		key, err := crypto.DeriveSessionKey(args.algorithm, args.secret, args.password, args.salt, args.transcript)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if bytes.Equal(key, base) {
+			t.Errorf("changing the %s does not change the key", name)
+		}
+	}
+
+	if _, err := crypto.DeriveSessionKey("AES", secret, password, salt[:7], transcript); !errors.Is(err, crypto.ErrInvalidSalt) {
+		t.Errorf("short salt: err = %v", err)
+	}
+	if _, err := crypto.DeriveSessionKey("Blowfish", secret, password, salt, transcript); !errors.Is(err, crypto.ErrUnsupportedAlgorithm) {
+		t.Errorf("unknown algorithm: err = %v", err)
+	}
+}
+
+// TestSessionKeySizes checks that every algorithm gets a session key of its
+// registered KeySize and that NewCipher accepts it
+func TestSessionKeySizes(t *testing.T) {
+	secret, password, salt := testKey(256), []byte("correct horse"), testKey(crypto.SaltSize)
+	transcript := crypto.Transcript(testKey(256), testMessage(256))
+
+	for _, alg := range crypto.Algorithms() {
+		key, err := crypto.DeriveSessionKey(alg.Name, secret, password, salt, transcript)
+		if err != nil {
+			t.Fatalf("%s: %v", alg.Name, err)
+		}
+		if len(key) != alg.KeySize {
+			t.Errorf("%s: %d-byte key, want %d", alg.Name, len(key), alg.KeySize)
+		}
+		if _, err := crypto.NewCipher(alg.Name, key); err != nil {
+			t.Errorf("%s: derived key rejected: %v", alg.Name, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package crypto
+
+import "errors"
+
+// Constants for MAGENTA
+const (
+	// MagentaBlockSize is the MAGENTA block size in bytes
+	MagentaBlockSize = 16
+	// magentaPoly is the primitive polynomial x^8+x^6+x^5+x^2+1 used to build the S-box
+	magentaPoly = 0x165
+)
+
+// magentaF is the S-box f(x) = alpha^x in GF(2^8), with f(255) = 0
+var magentaF [256]byte
+
+func init() {
+	x := uint32(1)
+	for i := 0; i < 255; i++ {
+		magentaF[i] = byte(x)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= magentaPoly
+		}
+	}
+	magentaF[255] = 0
+
+	Register(Algorithm{
+		Name:      "MAGENTA",
+		BlockSize: MagentaBlockSize,
+		KeySizes:  KeySizes{Min: 16, Max: 32, Step: 8},
+		KeySize:   32,
+		New: func(key []byte, _ string) (Cipher, error) {
+			return NewMagenta(key)
+		},
+	})
+}
+
+// Magenta represents a MAGENTA cipher instance
+type Magenta struct {
+	blockModes
+	schedule [][]byte // 8-byte round keys in the order they are applied
+}
+
+// NewMagenta creates a new MAGENTA cipher instance. 128 and 192-bit keys
+// use six rounds, 256-bit keys use eight.
+func NewMagenta(key []byte) (*Magenta, error) {
+	var order []int
+	switch len(key) {
+	case 16:
+		order = []int{0, 0, 1, 1, 0, 0}
+	case 24:
+		order = []int{0, 1, 2, 2, 1, 0}
+	case 32:
+		order = []int{0, 1, 2, 3, 3, 2, 1, 0}
+	default:
+		return nil, errors.New("magenta: key must be 16, 24, or 32 bytes")
+	}
+
+	m := &Magenta{schedule: make([][]byte, len(order))}
+	m.blockModes = blockModes{m}
+	for r, k := range order {
+		m.schedule[r] = append([]byte(nil), key[8*k:8*k+8]...)
+	}
+	return m, nil
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (m *Magenta) BlockSize() int {
+	return MagentaBlockSize
+}
+
+// magentaA computes A(x, y) = f(x xor f(y))
+func magentaA(x, y byte) byte {
+	return magentaF[x^magentaF[y]]
+}
+
+// magentaPi applies PE(x, y) = (A(x, y), A(y, x)) to each pair (x[i], x[i+8])
+func magentaPi(x *[16]byte) {
+	var out [16]byte
+	for i := 0; i < 8; i++ {
+		out[2*i] = magentaA(x[i], x[i+8])
+		out[2*i+1] = magentaA(x[i+8], x[i])
+	}
+	*x = out
+}
+
+// magentaT applies four rounds of Pi
+func magentaT(x *[16]byte) {
+	for i := 0; i < 4; i++ {
+		magentaPi(x)
+	}
+}
+
+// magentaS moves the even-indexed bytes before the odd-indexed ones
+func magentaS(x *[16]byte) {
+	var out [16]byte
+	for i := 0; i < 8; i++ {
+		out[i] = x[2*i]
+		out[i+8] = x[2*i+1]
+	}
+	*x = out
+}
+
+// magentaRound computes the round function F(x2, key), the first half of S(C(3, x2 | key))
+func magentaRound(x2, key []byte) [8]byte {
+	var w, c [16]byte
+	copy(w[:8], x2)
+	copy(w[8:], key)
+
+	// C(1, w) = T(w), C(j, w) = T(w xor S(C(j-1, w)))
+	c = w
+	magentaT(&c)
+	for j := 2; j <= 3; j++ {
+		magentaS(&c)
+		for i := range c {
+			c[i] ^= w[i]
+		}
+		magentaT(&c)
+	}
+	magentaS(&c)
+
+	var f [8]byte
+	copy(f[:], c[:8])
+	return f
+}
+
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (m *Magenta) encryptBlock(dst, src []byte) {
+	var x [16]byte
+	copy(x[:], src)
+	m.feistel(&x)
+	copy(dst, x[:])
+}
+
+// decryptBlock decrypts one block from src into dst, which may overlap.
+// The key schedule is symmetric, so decryption is encryption with the
+// halves swapped on the way in and out.
+func (m *Magenta) decryptBlock(dst, src []byte) {
+	var x [16]byte
+	copy(x[:8], src[8:16])
+	copy(x[8:], src[:8])
+	m.feistel(&x)
+	copy(dst[:8], x[8:])
+	copy(dst[8:16], x[:8])
+}
+
+// feistel runs the Feistel rounds E(x1, x2) = (x2, x1 xor F(x2, key)) over x
+func (m *Magenta) feistel(x *[16]byte) {
+	for _, key := range m.schedule {
+		f := magentaRound(x[8:], key)
+		var next [16]byte
+		copy(next[:8], x[8:])
+		for i := 0; i < 8; i++ {
+			next[8+i] = x[i] ^ f[i]
+		}
+		*x = next
+	}
+}
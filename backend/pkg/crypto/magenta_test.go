@@ -0,0 +1,126 @@
+package crypto_test
+
+import (
+	"bytes"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+// MAGENTA is unverified: no published test vectors are bundled, and
+// magentaReference below was written by the same author as magenta.go.
+// Agreement between the two only shows that they read the specification
+// the same way, not that either matches the submission's known answers.
+
+// magentaReference is MAGENTA as described in M. J. Jacobson and K. Huber,
+// "The MAGENTA Block Cipher Algorithm", 1998, written directly from the
+// definitions: f(x) = alpha^x over x^8+x^6+x^5+x^2+1 with f(255) = 0,
+// A(x, y) = f(x xor f(y)), PE(x, y) = (A(x, y), A(y, x)), T = Pi^4,
+// C(1, w) = T(w), C(n, w) = T(w xor S(C(n-1, w))) and the round function
+// F(x2, k) taken from the first half of S(C(3, x2 | k)).
+func magentaReference(key, pt []byte, decrypt bool) []byte {
+	f := func(x byte) byte {
+		if x == 255 {
+			return 0
+		}
+		a := 1
+		for i := 0; i < int(x); i++ {
+			a <<= 1
+			if a&0x100 != 0 {
+				a ^= 0x165
+			}
+		}
+		return byte(a)
+	}
+	A := func(x, y byte) byte { return f(x ^ f(y)) }
+	pi := func(x []byte) []byte {
+		out := make([]byte, 0, 16)
+		for i := 0; i < 8; i++ {
+			out = append(out, A(x[i], x[i+8]), A(x[i+8], x[i]))
+		}
+		return out
+	}
+	T := func(x []byte) []byte { return pi(pi(pi(pi(x)))) }
+	S := func(x []byte) []byte {
+		out := make([]byte, 0, 16)
+		for i := 0; i < 16; i += 2 {
+			out = append(out, x[i])
+		}
+		for i := 1; i < 16; i += 2 {
+			out = append(out, x[i])
+		}
+		return out
+	}
+	F := func(x2, k []byte) []byte {
+		w := append(append([]byte(nil), x2...), k...)
+		c := T(w)
+		for n := 2; n <= 3; n++ {
+			s := S(c)
+			for i := range s {
+				s[i] ^= w[i]
+			}
+			c = T(s)
+		}
+		return S(c)[:8]
+	}
+
+	k := func(i int) []byte { return key[8*i : 8*i+8] }
+	var keys [][]byte
+	switch len(key) {
+	case 16:
+		keys = [][]byte{k(0), k(0), k(1), k(1), k(0), k(0)}
+	case 24:
+		keys = [][]byte{k(0), k(1), k(2), k(2), k(1), k(0)}
+	case 32:
+		keys = [][]byte{k(0), k(1), k(2), k(3), k(3), k(2), k(1), k(0)}
+	}
+
+	// Decryption is V(Enc(V(x))), where V swaps the halves
+	x1, x2 := pt[:8], pt[8:]
+	if decrypt {
+		x1, x2 = x2, x1
+	}
+	for _, rk := range keys {
+		next := F(x2, rk)
+		for i := range next {
+			next[i] ^= x1[i]
+		}
+		x1, x2 = x2, next
+	}
+	if decrypt {
+		x1, x2 = x2, x1
+	}
+	return append(append([]byte(nil), x1...), x2...)
+}
+
+// TestMagentaMatchesReference checks magenta.go against magentaReference in
+// both directions; it is a consistency check, not a known-answer test
+func TestMagentaMatchesReference(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		keys := [][]byte{make([]byte, size), testKey(size), bytes.Repeat([]byte{0xFF}, size)}
+		for _, key := range keys {
+			c, err := crypto.NewMagenta(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, pt := range [][]byte{make([]byte, 16), testMessage(16), bytes.Repeat([]byte{0xFF}, 16)} {
+				want := magentaReference(key, pt, false)
+				got, err := c.Encrypt(pt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("key %X, pt %X: got %X, want %X", key, pt, got, want)
+				}
+
+				back, err := c.Decrypt(got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(back, pt) || !bytes.Equal(magentaReference(key, got, true), pt) {
+					t.Errorf("key %X, pt %X: decryption does not invert", key, pt)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,597 @@
+package crypto
+
+// Mode represents a block cipher mode of operation
+type Mode interface {
+	// Encrypt encrypts data using the given IV
+	Encrypt(data []byte, iv []byte) ([]byte, error)
+
+	// Decrypt decrypts data using the given IV
+	Decrypt(ciphertext []byte, iv []byte) ([]byte, error)
+}
+
+// blockStream processes data incrementally, carrying the chaining state
+// between calls. Every call except the last must pass whole blocks, and
+// dst must not overlap src.
+type blockStream interface {
+	crypt(dst, src []byte) error
+}
+
+// streamMode is implemented by modes that can process data incrementally
+type streamMode interface {
+	Mode
+	encrypter(iv []byte) (blockStream, error)
+	decrypter(iv []byte) (blockStream, error)
+}
+
+// ECBMode implements Electronic Codebook mode
+type ECBMode struct {
+	c Cipher
+}
+
+// NewECBMode creates a new ECB mode instance
+func NewECBMode(c Cipher) *ECBMode {
+	return &ECBMode{c: c}
+}
+
+// Encrypt encrypts each block independently, the IV is ignored
+func (m *ECBMode) Encrypt(data []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.encrypter, data, iv)
+}
+
+// Decrypt decrypts each block independently, the IV is ignored
+func (m *ECBMode) Decrypt(ciphertext []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.decrypter, ciphertext, iv)
+}
+
+func (m *ECBMode) encrypter(iv []byte) (blockStream, error) {
+	return &ecbStream{c: m.c}, nil
+}
+
+func (m *ECBMode) decrypter(iv []byte) (blockStream, error) {
+	return &ecbStream{c: m.c, decrypt: true}, nil
+}
+
+type ecbStream struct {
+	c       Cipher
+	decrypt bool
+}
+
+func (s *ecbStream) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	if len(src)%bs != 0 {
+		return ErrInvalidBlockSize
+	}
+
+	// Blocks are independent, so the input is split across goroutines
+	return parallelBlocks(len(src), bs, func(start, end int) error {
+		for i := start; i < end; i += bs {
+			var err error
+			if s.decrypt {
+				err = decryptInto(s.c, dst[i:i+bs], src[i:i+bs])
+			} else {
+				err = encryptInto(s.c, dst[i:i+bs], src[i:i+bs])
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CBCMode implements Cipher Block Chaining mode
+type CBCMode struct {
+	c Cipher
+}
+
+// NewCBCMode creates a new CBC mode instance
+func NewCBCMode(c Cipher) *CBCMode {
+	return &CBCMode{c: c}
+}
+
+// Encrypt XORs each plaintext block with the previous ciphertext block before encryption
+func (m *CBCMode) Encrypt(data []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.encrypter, data, iv)
+}
+
+// Decrypt reverses Encrypt
+func (m *CBCMode) Decrypt(ciphertext []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.decrypter, ciphertext, iv)
+}
+
+func (m *CBCMode) encrypter(iv []byte) (blockStream, error) {
+	prev, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &cbcEncrypter{c: m.c, prev: prev}, nil
+}
+
+func (m *CBCMode) decrypter(iv []byte) (blockStream, error) {
+	prev, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &cbcDecrypter{c: m.c, prev: prev}, nil
+}
+
+type cbcEncrypter struct {
+	c    Cipher
+	prev []byte // previous ciphertext block, or the IV
+}
+
+func (s *cbcEncrypter) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	if len(src)%bs != 0 {
+		return ErrInvalidBlockSize
+	}
+
+	for i := 0; i < len(src); i += bs {
+		xorBytes(s.prev, src[i:i+bs], s.prev)
+		if err := encryptInto(s.c, dst[i:i+bs], s.prev); err != nil {
+			return err
+		}
+		copy(s.prev, dst[i:i+bs])
+	}
+	return nil
+}
+
+type cbcDecrypter struct {
+	c    Cipher
+	prev []byte // previous ciphertext block, or the IV
+}
+
+func (s *cbcDecrypter) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	if len(src)%bs != 0 {
+		return ErrInvalidBlockSize
+	}
+	if len(src) == 0 {
+		return nil
+	}
+
+	// Every ciphertext block is already known, so blocks decrypt in parallel
+	err := parallelBlocks(len(src), bs, func(start, end int) error {
+		for i := start; i < end; i += bs {
+			if err := decryptInto(s.c, dst[i:i+bs], src[i:i+bs]); err != nil {
+				return err
+			}
+			if i == 0 {
+				xorBytes(dst[i:i+bs], dst[i:i+bs], s.prev)
+			} else {
+				xorBytes(dst[i:i+bs], dst[i:i+bs], src[i-bs:i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	copy(s.prev, src[len(src)-bs:])
+	return nil
+}
+
+// PCBCMode implements Propagating Cipher Block Chaining mode
+type PCBCMode struct {
+	c Cipher
+}
+
+// NewPCBCMode creates a new PCBC mode instance
+func NewPCBCMode(c Cipher) *PCBCMode {
+	return &PCBCMode{c: c}
+}
+
+// Encrypt XORs each plaintext block with both the previous plaintext and ciphertext blocks
+func (m *PCBCMode) Encrypt(data []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.encrypter, data, iv)
+}
+
+// Decrypt reverses Encrypt
+func (m *PCBCMode) Decrypt(ciphertext []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.decrypter, ciphertext, iv)
+}
+
+func (m *PCBCMode) encrypter(iv []byte) (blockStream, error) {
+	chain, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &pcbcStream{c: m.c, chain: chain, tmp: make([]byte, len(chain))}, nil
+}
+
+func (m *PCBCMode) decrypter(iv []byte) (blockStream, error) {
+	chain, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &pcbcStream{c: m.c, chain: chain, tmp: make([]byte, len(chain)), decrypt: true}, nil
+}
+
+type pcbcStream struct {
+	c       Cipher
+	chain   []byte // P[i-1] ^ C[i-1], or the IV for the first block
+	tmp     []byte
+	decrypt bool
+}
+
+func (s *pcbcStream) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	if len(src)%bs != 0 {
+		return ErrInvalidBlockSize
+	}
+
+	if s.decrypt {
+		// Block decryption is independent, only the chaining XOR is sequential
+		err := parallelBlocks(len(src), bs, func(start, end int) error {
+			for i := start; i < end; i += bs {
+				if err := decryptInto(s.c, dst[i:i+bs], src[i:i+bs]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(src); i += bs {
+			xorBytes(dst[i:i+bs], dst[i:i+bs], s.chain)
+			xorBytes(s.chain, dst[i:i+bs], src[i:i+bs])
+		}
+		return nil
+	}
+
+	for i := 0; i < len(src); i += bs {
+		in := src[i : i+bs]
+		xorBytes(s.tmp, in, s.chain)
+		if err := encryptInto(s.c, dst[i:i+bs], s.tmp); err != nil {
+			return err
+		}
+		xorBytes(s.chain, in, dst[i:i+bs])
+	}
+	return nil
+}
+
+// CFBMode implements full-block Cipher Feedback mode
+type CFBMode struct {
+	c Cipher
+}
+
+// NewCFBMode creates a new CFB mode instance
+func NewCFBMode(c Cipher) *CFBMode {
+	return &CFBMode{c: c}
+}
+
+// Encrypt XORs the data with the encryption of the previous ciphertext block.
+// The last block may be partial.
+func (m *CFBMode) Encrypt(data []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.encrypter, data, iv)
+}
+
+// Decrypt reverses Encrypt
+func (m *CFBMode) Decrypt(ciphertext []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.decrypter, ciphertext, iv)
+}
+
+func (m *CFBMode) encrypter(iv []byte) (blockStream, error) {
+	feedback, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &cfbStream{c: m.c, feedback: feedback, stream: make([]byte, len(feedback))}, nil
+}
+
+func (m *CFBMode) decrypter(iv []byte) (blockStream, error) {
+	feedback, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &cfbStream{c: m.c, feedback: feedback, stream: make([]byte, len(feedback)), decrypt: true}, nil
+}
+
+type cfbStream struct {
+	c        Cipher
+	feedback []byte // previous ciphertext block, or the IV
+	stream   []byte
+	decrypt  bool
+}
+
+func (s *cfbStream) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	for i := 0; i < len(src); i += bs {
+		end := minInt(i+bs, len(src))
+		if err := encryptInto(s.c, s.stream, s.feedback); err != nil {
+			return err
+		}
+		if s.decrypt {
+			copy(s.feedback, src[i:end])
+			xorBytes(dst[i:end], src[i:end], s.stream)
+		} else {
+			xorBytes(dst[i:end], src[i:end], s.stream)
+			copy(s.feedback, dst[i:end])
+		}
+	}
+	return nil
+}
+
+// OFBMode implements Output Feedback mode
+type OFBMode struct {
+	c Cipher
+}
+
+// NewOFBMode creates a new OFB mode instance
+func NewOFBMode(c Cipher) *OFBMode {
+	return &OFBMode{c: c}
+}
+
+// Encrypt XORs the data with a keystream produced by repeatedly encrypting the IV.
+// The last block may be partial.
+func (m *OFBMode) Encrypt(data []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.encrypter, data, iv)
+}
+
+// Decrypt is identical to Encrypt in OFB mode
+func (m *OFBMode) Decrypt(ciphertext []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.decrypter, ciphertext, iv)
+}
+
+func (m *OFBMode) encrypter(iv []byte) (blockStream, error) {
+	stream, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &ofbStream{c: m.c, stream: stream}, nil
+}
+
+func (m *OFBMode) decrypter(iv []byte) (blockStream, error) {
+	return m.encrypter(iv)
+}
+
+type ofbStream struct {
+	c      Cipher
+	stream []byte // last keystream block, or the IV
+}
+
+func (s *ofbStream) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	for i := 0; i < len(src); i += bs {
+		end := minInt(i+bs, len(src))
+		if err := encryptInto(s.c, s.stream, s.stream); err != nil {
+			return err
+		}
+		xorBytes(dst[i:end], src[i:end], s.stream)
+	}
+	return nil
+}
+
+// CTRMode implements Counter mode. The IV is the initial counter value,
+// incremented as a big-endian integer over the whole block.
+type CTRMode struct {
+	c Cipher
+}
+
+// NewCTRMode creates a new CTR mode instance
+func NewCTRMode(c Cipher) *CTRMode {
+	return &CTRMode{c: c}
+}
+
+// Encrypt XORs the data with the encryption of successive counter values.
+// The last block may be partial.
+func (m *CTRMode) Encrypt(data []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.encrypter, data, iv)
+}
+
+// Decrypt is identical to Encrypt in CTR mode
+func (m *CTRMode) Decrypt(ciphertext []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.decrypter, ciphertext, iv)
+}
+
+func (m *CTRMode) encrypter(iv []byte) (blockStream, error) {
+	counter, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &ctrStream{c: m.c, counter: counter}, nil
+}
+
+func (m *CTRMode) decrypter(iv []byte) (blockStream, error) {
+	return m.encrypter(iv)
+}
+
+type ctrStream struct {
+	c       Cipher
+	counter []byte
+}
+
+func (s *ctrStream) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	blocks := (len(src) + bs - 1) / bs
+
+	// Each goroutine starts from its own copy of the counter, advanced to the
+	// first block of its range; a trailing partial block stays in the last range
+	err := parallelBlocks(blocks*bs, bs, func(start, end int) error {
+		counter := append([]byte(nil), s.counter...)
+		addCounter(counter, uint64(start/bs))
+		stream := make([]byte, bs)
+
+		for i := start; i < end && i < len(src); i += bs {
+			e := minInt(i+bs, len(src))
+			if err := encryptInto(s.c, stream, counter); err != nil {
+				return err
+			}
+			xorBytes(dst[i:e], src[i:e], stream)
+			incrementCounter(counter)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	addCounter(s.counter, uint64(blocks))
+	return nil
+}
+
+// RandomDeltaMode implements the Random Delta counter mode. The IV is the
+// initial counter value and also seeds the delta: its second half, read as a
+// big-endian integer and forced odd, is added to the counter after every block.
+type RandomDeltaMode struct {
+	c Cipher
+}
+
+// NewRandomDeltaMode creates a new Random Delta mode instance
+func NewRandomDeltaMode(c Cipher) *RandomDeltaMode {
+	return &RandomDeltaMode{c: c}
+}
+
+// Encrypt XORs the data with the encryption of successive counter values.
+// The last block may be partial.
+func (m *RandomDeltaMode) Encrypt(data []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.encrypter, data, iv)
+}
+
+// Decrypt is identical to Encrypt in Random Delta mode
+func (m *RandomDeltaMode) Decrypt(ciphertext []byte, iv []byte) ([]byte, error) {
+	return cryptAll(m.decrypter, ciphertext, iv)
+}
+
+func (m *RandomDeltaMode) encrypter(iv []byte) (blockStream, error) {
+	counter, err := copyIV(m.c, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	// An odd delta makes the counter cycle through every block value
+	delta := make([]byte, len(counter))
+	copy(delta[len(delta)/2:], counter[len(counter)/2:])
+	delta[len(delta)-1] |= 1
+
+	return &randomDeltaStream{
+		c:       m.c,
+		counter: counter,
+		delta:   delta,
+		stream:  make([]byte, len(counter)),
+	}, nil
+}
+
+func (m *RandomDeltaMode) decrypter(iv []byte) (blockStream, error) {
+	return m.encrypter(iv)
+}
+
+type randomDeltaStream struct {
+	c       Cipher
+	counter []byte
+	delta   []byte
+	stream  []byte
+}
+
+func (s *randomDeltaStream) crypt(dst, src []byte) error {
+	bs := s.c.BlockSize()
+	for i := 0; i < len(src); i += bs {
+		end := minInt(i+bs, len(src))
+		if err := encryptInto(s.c, s.stream, s.counter); err != nil {
+			return err
+		}
+		xorBytes(dst[i:end], src[i:end], s.stream)
+		addBytes(s.counter, s.delta)
+	}
+	return nil
+}
+
+// Helper functions
+
+// cryptAll runs a fresh stream over the whole input
+func cryptAll(newStream func(iv []byte) (blockStream, error), data, iv []byte) ([]byte, error) {
+	s, err := newStream(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	if err := s.crypt(out, data); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// copyIV validates the IV length and returns a copy the stream can modify
+func copyIV(c Cipher, iv []byte) ([]byte, error) {
+	if len(iv) != c.BlockSize() {
+		return nil, ErrInvalidIV
+	}
+	return append([]byte(nil), iv...), nil
+}
+
+// encryptInto encrypts one block of src into dst, in place when c supports it
+func encryptInto(c Cipher, dst, src []byte) error {
+	if bc, ok := c.(blockCipher); ok {
+		bc.encryptBlock(dst, src)
+		return nil
+	}
+
+	out, err := c.Encrypt(src)
+	if err != nil {
+		return err
+	}
+	copy(dst, out)
+	return nil
+}
+
+// decryptInto decrypts one block of src into dst, in place when c supports it
+func decryptInto(c Cipher, dst, src []byte) error {
+	if bc, ok := c.(blockCipher); ok {
+		bc.decryptBlock(dst, src)
+		return nil
+	}
+
+	out, err := c.Decrypt(src)
+	if err != nil {
+		return err
+	}
+	copy(dst, out)
+	return nil
+}
+
+// xorBytes sets dst[i] = a[i] ^ b[i] for i < len(dst)
+func xorBytes(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
+
+// incrementCounter adds one to a big-endian counter, wrapping on overflow
+func incrementCounter(counter []byte) {
+	for i := len(counter) - 1; i >= 0; i-- {
+		counter[i]++
+		if counter[i] != 0 {
+			return
+		}
+	}
+}
+
+// addBytes adds the big-endian integer delta to counter of the same length, wrapping on overflow
+func addBytes(counter, delta []byte) {
+	carry := uint16(0)
+	for i := len(counter) - 1; i >= 0; i-- {
+		sum := uint16(counter[i]) + uint16(delta[i]) + carry
+		counter[i] = byte(sum)
+		carry = sum >> 8
+	}
+}
+
+// addCounter adds n to a big-endian counter, wrapping on overflow
+func addCounter(counter []byte, n uint64) {
+	for i := len(counter) - 1; i >= 0 && n != 0; i-- {
+		sum := uint64(counter[i]) + n&0xFF
+		counter[i] = byte(sum)
+		n = n>>8 + sum>>8
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
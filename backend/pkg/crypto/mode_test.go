@@ -0,0 +1,229 @@
+package crypto_test
+
+import (
+	"bytes"
+	stdcipher "crypto/cipher"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+// testMessage returns n bytes with no trailing zero, so Zeros padding
+// round-trips
+func testMessage(n int) []byte {
+	msg := make([]byte, n)
+	for i := range msg {
+		msg[i] = byte(i*7 + 1)
+	}
+	return msg
+}
+
+func testKey(n int) []byte {
+	key := make([]byte, n)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	return key
+}
+
+type modeCipher struct {
+	name  string
+	c     crypto.Cipher
+	block stdcipher.Block
+}
+
+func modeCiphers(t *testing.T) []modeCipher {
+	t.Helper()
+
+	var ciphers []modeCipher
+	for _, w := range []int{16, 32, 64} {
+		c, err := crypto.NewRC5(w, 12, testKey(16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := crypto.NewRC5Block(w, 12, testKey(16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphers = append(ciphers, modeCipher{fmt.Sprintf("RC5-%d", w), c, b})
+	}
+
+	c, err := crypto.NewTwoFish(testKey(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := crypto.NewTwoFishBlock(testKey(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(ciphers, modeCipher{"TwoFish", c, b})
+}
+
+func TestModeRoundTrip(t *testing.T) {
+	for _, mc := range modeCiphers(t) {
+		iv := testMessage(mc.c.BlockSize())
+		for _, mode := range crypto.Modes {
+			for _, padding := range crypto.Paddings {
+				for _, n := range []int{0, 1, mc.c.BlockSize(), 3*mc.c.BlockSize() + 5} {
+					msg := testMessage(n)
+					ct, err := mc.c.EncryptWithMode(msg, iv, mode, padding)
+					if err != nil {
+						t.Fatalf("%s %s %v: %v", mc.name, mode, padding, err)
+					}
+					pt, err := mc.c.DecryptWithMode(ct, iv, mode, padding)
+					if err != nil {
+						t.Fatalf("%s %s %v: %v", mc.name, mode, padding, err)
+					}
+					if !bytes.Equal(pt, msg) {
+						t.Errorf("%s %s %v, %d bytes: got %X", mc.name, mode, padding, n, pt)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestModeMatchesStdlib checks the package's modes against crypto/cipher
+// running over the Block adapter
+func TestModeMatchesStdlib(t *testing.T) {
+	for _, mc := range modeCiphers(t) {
+		bs := mc.c.BlockSize()
+		iv := testMessage(bs)
+		msg := testMessage(4*bs + 3)
+		padded, err := crypto.Pad(msg, bs, crypto.PKCS7)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string][]byte{}
+		for _, mode := range []string{"ECB", "CBC", "CFB", "OFB", "CTR"} {
+			want[mode] = make([]byte, len(padded))
+		}
+		for i := 0; i < len(padded); i += bs {
+			mc.block.Encrypt(want["ECB"][i:], padded[i:])
+		}
+		stdcipher.NewCBCEncrypter(mc.block, iv).CryptBlocks(want["CBC"], padded)
+		stdcipher.NewCFBEncrypter(mc.block, iv).XORKeyStream(want["CFB"], padded)
+		stdcipher.NewOFB(mc.block, iv).XORKeyStream(want["OFB"], padded)
+		stdcipher.NewCTR(mc.block, iv).XORKeyStream(want["CTR"], padded)
+
+		for mode, w := range want {
+			got, err := mc.c.EncryptWithMode(msg, iv, mode, crypto.PKCS7)
+			if err != nil {
+				t.Fatalf("%s %s: %v", mc.name, mode, err)
+			}
+			if !bytes.Equal(got, w) {
+				t.Errorf("%s %s: got %X, want %X", mc.name, mode, got, w)
+			}
+		}
+	}
+}
+
+// TestRandomDelta checks Random Delta against its definition: the keystream
+// is the encryption of iv, iv+delta, iv+2*delta, ... where delta is the
+// second half of the IV forced odd
+func TestRandomDelta(t *testing.T) {
+	for _, mc := range modeCiphers(t) {
+		bs := mc.c.BlockSize()
+		iv := testMessage(bs)
+		msg := testMessage(5*bs + 3)
+
+		delta := new(big.Int).SetBytes(iv[bs/2:])
+		delta.SetBit(delta, 0, 1)
+		modulus := new(big.Int).Lsh(big.NewInt(1), uint(8*bs))
+		counter := new(big.Int).SetBytes(iv)
+
+		want := make([]byte, len(msg))
+		stream := make([]byte, bs)
+		for i := 0; i < len(msg); i += bs {
+			mc.block.Encrypt(stream, counter.FillBytes(make([]byte, bs)))
+			for j := i; j < len(msg) && j < i+bs; j++ {
+				want[j] = msg[j] ^ stream[j-i]
+			}
+			counter.Add(counter, delta).Mod(counter, modulus)
+		}
+
+		got, err := mc.c.EncryptWithMode(msg, iv, "RandomDelta", crypto.PKCS7)
+		if err != nil {
+			t.Fatalf("%s: %v", mc.name, err)
+		}
+		// PKCS7 adds a partial block of padding to the 3 trailing bytes
+		if !bytes.Equal(got[:len(msg)], want) {
+			t.Errorf("%s: got %X, want %X", mc.name, got[:len(msg)], want)
+		}
+
+		pt, err := mc.c.DecryptWithMode(got, iv, "RandomDelta", crypto.PKCS7)
+		if err != nil {
+			t.Fatalf("%s: %v", mc.name, err)
+		}
+		if !bytes.Equal(pt, msg) {
+			t.Errorf("%s: round trip got %X", mc.name, pt)
+		}
+	}
+}
+
+// TestRandomDeltaIV checks that the IV changes the whole ciphertext and
+// that a wrong-length IV is rejected
+func TestRandomDeltaIV(t *testing.T) {
+	for _, mc := range modeCiphers(t) {
+		bs := mc.c.BlockSize()
+		msg := testMessage(4 * bs)
+		iv := testMessage(bs)
+		other := append([]byte(nil), iv...)
+		other[bs-1] ^= 2
+
+		a, err := mc.c.EncryptWithMode(msg, iv, "RandomDelta", crypto.Zeros)
+		if err != nil {
+			t.Fatalf("%s: %v", mc.name, err)
+		}
+		b, err := mc.c.EncryptWithMode(msg, other, "RandomDelta", crypto.Zeros)
+		if err != nil {
+			t.Fatalf("%s: %v", mc.name, err)
+		}
+		for i := 0; i < len(a); i += bs {
+			if bytes.Equal(a[i:i+bs], b[i:i+bs]) {
+				t.Errorf("%s: block %d unchanged by a different delta", mc.name, i/bs)
+			}
+		}
+
+		if _, err := mc.c.EncryptWithMode(msg, iv[:bs-1], "RandomDelta", crypto.Zeros); err == nil {
+			t.Errorf("%s: short IV accepted", mc.name)
+		}
+	}
+}
+
+// TestModeParallel checks that splitting a large message across goroutines
+// gives the same output as processing it sequentially
+func TestModeParallel(t *testing.T) {
+	old := crypto.Parallelism()
+	t.Cleanup(func() { crypto.SetParallelism(old) })
+
+	for _, mc := range modeCiphers(t) {
+		bs := mc.c.BlockSize()
+		iv := testMessage(bs)
+		msg := testMessage(80*1024 + 3)
+		for _, mode := range crypto.Modes {
+			var results [][]byte
+			for _, n := range []int{1, 8} {
+				crypto.SetParallelism(n)
+				ct, err := mc.c.EncryptWithMode(msg, iv, mode, crypto.PKCS7)
+				if err != nil {
+					t.Fatalf("%s %s, parallelism %d: %v", mc.name, mode, n, err)
+				}
+				pt, err := mc.c.DecryptWithMode(ct, iv, mode, crypto.PKCS7)
+				if err != nil {
+					t.Fatalf("%s %s, parallelism %d: %v", mc.name, mode, n, err)
+				}
+				if !bytes.Equal(pt, msg) {
+					t.Errorf("%s %s, parallelism %d: round trip failed", mc.name, mode, n)
+				}
+				results = append(results, ct)
+			}
+			if !bytes.Equal(results[0], results[1]) {
+				t.Errorf("%s %s: parallel ciphertext differs from sequential", mc.name, mode)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package crypto
+
+import "crypto/rand"
+
+// PaddingType identifies a block padding scheme. The values match entity.Padding.
+type PaddingType string
+
+const (
+	// Zeros pads with zero bytes, adding nothing if the data is already aligned
+	Zeros PaddingType = "Zeros"
+	// PKCS7 pads with N bytes of value N
+	PKCS7 PaddingType = "PKCS7"
+	// ISO10126 pads with N-1 random bytes followed by N
+	ISO10126 PaddingType = "ISO10126"
+	// ANSIX923 pads with N-1 zero bytes followed by N
+	ANSIX923 PaddingType = "ANSIX923"
+)
+
+// Paddings lists the supported padding schemes
+var Paddings = []PaddingType{Zeros, PKCS7, ISO10126, ANSIX923}
+
+// Pad pads data to a multiple of blockSize using the given scheme
+func Pad(data []byte, blockSize int, padding PaddingType) ([]byte, error) {
+	padLen := blockSize - len(data)%blockSize
+	if padding == Zeros && padLen == blockSize {
+		padLen = 0
+	}
+
+	padded := make([]byte, len(data)+padLen)
+	copy(padded, data)
+	if padLen == 0 {
+		return padded, nil
+	}
+	tail := padded[len(data):]
+
+	switch padding {
+	case Zeros:
+		// already zeroed by make
+	case PKCS7:
+		for i := range tail {
+			tail[i] = byte(padLen)
+		}
+	case ISO10126:
+		if _, err := rand.Read(tail[:padLen-1]); err != nil {
+			return nil, err
+		}
+		tail[padLen-1] = byte(padLen)
+	case ANSIX923:
+		tail[padLen-1] = byte(padLen)
+	default:
+		return nil, ErrUnsupportedPadding
+	}
+
+	return padded, nil
+}
+
+// Unpad removes padding added by Pad and validates its structure
+func Unpad(data []byte, blockSize int, padding PaddingType) ([]byte, error) {
+	if len(data)%blockSize != 0 {
+		return nil, ErrInvalidPadding
+	}
+
+	if padding == Zeros {
+		// Zero padding never spans more than the last block
+		end := len(data)
+		for end > 0 && end > len(data)-blockSize && data[end-1] == 0 {
+			end--
+		}
+		return data[:end], nil
+	}
+
+	if len(data) == 0 {
+		return nil, ErrInvalidPadding
+	}
+
+	padLen := int(data[len(data)-1])
+	if padLen < 1 || padLen > blockSize {
+		return nil, ErrInvalidPadding
+	}
+	tail := data[len(data)-padLen : len(data)-1]
+
+	switch padding {
+	case PKCS7:
+		for _, b := range tail {
+			if int(b) != padLen {
+				return nil, ErrInvalidPadding
+			}
+		}
+	case ISO10126:
+		// filler bytes are random and cannot be checked
+	case ANSIX923:
+		for _, b := range tail {
+			if b != 0 {
+				return nil, ErrInvalidPadding
+			}
+		}
+	default:
+		return nil, ErrUnsupportedPadding
+	}
+
+	return data[:len(data)-padLen], nil
+}
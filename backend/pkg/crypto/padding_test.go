@@ -0,0 +1,63 @@
+package crypto_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+func TestPadRoundTrip(t *testing.T) {
+	for _, padding := range crypto.Paddings {
+		for n := 0; n <= 17; n++ {
+			msg := testMessage(n)
+			padded, err := crypto.Pad(msg, 8, padding)
+			if err != nil {
+				t.Fatalf("%v: %v", padding, err)
+			}
+			if len(padded)%8 != 0 || len(padded) < n {
+				t.Errorf("%v, %d bytes: padded to %d", padding, n, len(padded))
+			}
+			got, err := crypto.Unpad(padded, 8, padding)
+			if err != nil || !bytes.Equal(got, msg) {
+				t.Errorf("%v, %d bytes: Unpad = %X, %v", padding, n, got, err)
+			}
+		}
+	}
+}
+
+func TestUnpadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		padding crypto.PaddingType
+		err     error
+	}{
+		{"PKCS7 bad filler", "0102030405030203", crypto.PKCS7, crypto.ErrInvalidPadding},
+		{"PKCS7 zero length", "0102030405060700", crypto.PKCS7, crypto.ErrInvalidPadding},
+		{"PKCS7 length above block", "0102030405060709", crypto.PKCS7, crypto.ErrInvalidPadding},
+		{"PKCS7 empty", "", crypto.PKCS7, crypto.ErrInvalidPadding},
+		{"PKCS7 misaligned", "01020304050603", crypto.PKCS7, crypto.ErrInvalidPadding},
+		{"ANSIX923 nonzero filler", "0102030405000103", crypto.ANSIX923, crypto.ErrInvalidPadding},
+		{"ANSIX923 zero length", "0102030405000000", crypto.ANSIX923, crypto.ErrInvalidPadding},
+		{"ISO10126 length above block", "0102030405060711", crypto.ISO10126, crypto.ErrInvalidPadding},
+		{"Zeros misaligned", "010203", crypto.Zeros, crypto.ErrInvalidPadding},
+		{"unknown scheme", "0102030405060701", "PKCS5", crypto.ErrUnsupportedPadding},
+	}
+
+	for _, tt := range tests {
+		data, err := hex.DecodeString(tt.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := crypto.Unpad(data, 8, tt.padding); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if _, err := crypto.Pad([]byte("abc"), 8, "PKCS5"); !errors.Is(err, crypto.ErrUnsupportedPadding) {
+		t.Errorf("Pad with unknown scheme: err = %v", err)
+	}
+}
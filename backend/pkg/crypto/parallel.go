@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelMinChunk is the smallest amount of data handed to a single
+// goroutine. Inputs shorter than this are processed sequentially.
+const parallelMinChunk = 16 * 1024
+
+var parallelism atomic.Int32
+
+func init() {
+	parallelism.Store(int32(runtime.GOMAXPROCS(0)))
+}
+
+// SetParallelism sets the maximum number of goroutines used by the ECB and
+// CTR modes and by CBC and PCBC decryption. Values below 2 disable
+// parallel processing. The default is GOMAXPROCS at startup.
+func SetParallelism(n int) {
+	if n < 1 {
+		n = 1
+	}
+	parallelism.Store(int32(n))
+}
+
+// Parallelism returns the current parallelism level
+func Parallelism() int {
+	return int(parallelism.Load())
+}
+
+// parallelBlocks splits length bytes of whole blocks into contiguous ranges
+// and runs fn on each of them, on up to Parallelism() goroutines.
+// It returns the first error reported by fn.
+func parallelBlocks(length, blockSize int, fn func(start, end int) error) error {
+	workers := minInt(Parallelism(), length/parallelMinChunk)
+	if workers < 2 {
+		return fn(0, length)
+	}
+
+	blocks := length / blockSize
+	chunk := (blocks + workers - 1) / workers * blockSize
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for start := 0; start < length; start += chunk {
+		end := minInt(start+chunk, length)
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			if err := fn(start, end); err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,207 @@
+package primes
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// ErrTooSmall is returned when a prime of the requested size cannot be generated
+var ErrTooSmall = errors.New("primes: bit length too small")
+
+// smallPrimes are the odd primes below 1000, used to sieve candidates
+// before running a probabilistic test
+var smallPrimes = func() []uint64 {
+	var ps []uint64
+	for n := uint64(3); n < 1000; n += 2 {
+		prime := true
+		for _, p := range ps {
+			if p*p > n {
+				break
+			}
+			if n%p == 0 {
+				prime = false
+				break
+			}
+		}
+		if prime {
+			ps = append(ps, n)
+		}
+	}
+	return ps
+}()
+
+// randomOdd returns a random odd number of exactly the given number of bits
+func randomOdd(bits int) (*big.Int, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(one, uint(bits-1)))
+	if err != nil {
+		return nil, err
+	}
+	n.SetBit(n, bits-1, 1)
+	return n.SetBit(n, 0, 1), nil
+}
+
+// probablyPrime reports whether test accepts every n, first with a single
+// round each, which rejects most composites cheaply, then at minProbability
+func probablyPrime(test PrimalityTest, minProbability float64, ns ...*big.Int) (bool, error) {
+	for _, probability := range []float64{0.5, minProbability} {
+		for _, n := range ns {
+			if ok, err := test.IsProbablyPrime(n, probability); err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// Random returns a random prime of exactly the given number of bits
+func Random(bits int, test PrimalityTest, minProbability float64) (*big.Int, error) {
+	if err := checkProbability(minProbability); err != nil {
+		return nil, err
+	}
+	if bits < 2 {
+		return nil, ErrTooSmall
+	}
+	if bits == 2 {
+		return big.NewInt(3), nil
+	}
+
+	for {
+		n, err := randomOdd(bits)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := probablyPrime(test, minProbability, n)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return n, nil
+		}
+	}
+}
+
+// Safe returns a random safe prime p = 2q + 1 of exactly the given number
+// of bits, where q is also prime. q is returned too.
+func Safe(bits int, test PrimalityTest, minProbability float64) (p, q *big.Int, err error) {
+	if err := checkProbability(minProbability); err != nil {
+		return nil, nil, err
+	}
+	if bits < 16 {
+		return nil, nil, ErrTooSmall
+	}
+
+	residues := make([]uint64, len(smallPrimes))
+	for {
+		start, err := randomOdd(bits - 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, sp := range smallPrimes {
+			residues[i] = new(big.Int).Mod(start, new(big.Int).SetUint64(sp)).Uint64()
+		}
+
+		// Search upwards from start, skipping any q for which q or 2q+1
+		// has a small factor
+	search:
+		for delta := uint64(0); delta < 1<<20; delta += 2 {
+			for i, sp := range smallPrimes {
+				r := (residues[i] + delta) % sp
+				if r == 0 || (2*r+1)%sp == 0 {
+					continue search
+				}
+			}
+
+			q = new(big.Int).Add(start, new(big.Int).SetUint64(delta))
+			if q.BitLen() != bits-1 {
+				break
+			}
+			p = new(big.Int).Lsh(q, 1)
+			p.Add(p, one)
+
+			ok, err := probablyPrime(test, minProbability, q, p)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				return p, q, nil
+			}
+		}
+	}
+}
+
+// Strong returns a strong prime p of exactly the given number of bits using
+// Gordon's algorithm: p-1 has a large prime factor r, p+1 has a large prime
+// factor s and r-1 has a large prime factor t. Such primes resist Pollard's
+// p-1 and Williams' p+1 factoring methods.
+func Strong(bits int, test PrimalityTest, minProbability float64) (*big.Int, error) {
+	if err := checkProbability(minProbability); err != nil {
+		return nil, err
+	}
+	if bits < 64 {
+		return nil, ErrTooSmall
+	}
+
+	for {
+		s, err := Random(bits/2-8, test, minProbability)
+		if err != nil {
+			return nil, err
+		}
+		t, err := Random(bits/2-16, test, minProbability)
+		if err != nil {
+			return nil, err
+		}
+
+		// r is the first prime of the form 2it + 1
+		twoT := new(big.Int).Lsh(t, 1)
+		r := new(big.Int).Add(twoT, one)
+		for {
+			ok, err := probablyPrime(test, minProbability, r)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				break
+			}
+			r.Add(r, twoT)
+		}
+
+		// p0 = 2 (s^(r-2) mod r) s - 1 is 1 mod r and -1 mod s
+		p0 := modPow(s, new(big.Int).Sub(r, two), r)
+		p0.Mul(p0, s)
+		p0.Lsh(p0, 1)
+		p0.Sub(p0, one)
+
+		// p = p0 + 2jrs keeps both congruences; start at the first such
+		// value with the requested number of bits
+		step := new(big.Int).Mul(r, s)
+		step.Lsh(step, 1)
+		lower := new(big.Int).Lsh(one, uint(bits-1))
+		j := new(big.Int).Sub(lower, p0)
+		j.Add(j, new(big.Int).Sub(step, one))
+		j.Quo(j, step)
+		if j.Sign() < 0 {
+			j.SetInt64(0)
+		}
+
+		// Randomize the starting point so repeated calls differ
+		offset, err := rand.Int(rand.Reader, big.NewInt(1<<16))
+		if err != nil {
+			return nil, err
+		}
+		j.Add(j, offset)
+
+		p := new(big.Int).Mul(j, step)
+		p.Add(p, p0)
+		for p.BitLen() == bits {
+			ok, err := probablyPrime(test, minProbability, p)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return p, nil
+			}
+			p.Add(p, step)
+		}
+	}
+}
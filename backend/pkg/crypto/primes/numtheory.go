@@ -0,0 +1,123 @@
+package primes
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrModulus          = errors.New("primes: modulus must be positive")
+	ErrNegativeExponent = errors.New("primes: exponent must not be negative")
+	ErrNotInvertible    = errors.New("primes: value is not invertible modulo m")
+	ErrJacobiModulus    = errors.New("primes: Jacobi symbol needs an odd positive n")
+)
+
+// ModPow returns base^exp mod m by left-to-right square and multiply.
+// exp must be non-negative and m positive.
+func ModPow(base, exp, m *big.Int) (*big.Int, error) {
+	if m.Sign() <= 0 {
+		return nil, ErrModulus
+	}
+	if exp.Sign() < 0 {
+		return nil, ErrNegativeExponent
+	}
+	return modPow(base, exp, m), nil
+}
+
+// modPow is ModPow for callers that already know exp >= 0 and m > 0
+func modPow(base, exp, m *big.Int) *big.Int {
+	if m.Cmp(one) == 0 {
+		return new(big.Int)
+	}
+
+	b := new(big.Int).Mod(base, m)
+	result := big.NewInt(1)
+	for i := exp.BitLen() - 1; i >= 0; i-- {
+		result.Mul(result, result)
+		result.Mod(result, m)
+		if exp.Bit(i) == 1 {
+			result.Mul(result, b)
+			result.Mod(result, m)
+		}
+	}
+	return result
+}
+
+// ExtendedGCD returns g = gcd(a, b) together with x and y such that
+// a*x + b*y = g
+func ExtendedGCD(a, b *big.Int) (g, x, y *big.Int) {
+	oldR, r := new(big.Int).Set(a), new(big.Int).Set(b)
+	oldS, s := big.NewInt(1), big.NewInt(0)
+	oldT, t := big.NewInt(0), big.NewInt(1)
+
+	for r.Sign() != 0 {
+		q := new(big.Int).Quo(oldR, r)
+		oldR, r = r, new(big.Int).Sub(oldR, new(big.Int).Mul(q, r))
+		oldS, s = s, new(big.Int).Sub(oldS, new(big.Int).Mul(q, s))
+		oldT, t = t, new(big.Int).Sub(oldT, new(big.Int).Mul(q, t))
+	}
+
+	// Keep the gcd non-negative
+	if oldR.Sign() < 0 {
+		oldR.Neg(oldR)
+		oldS.Neg(oldS)
+		oldT.Neg(oldT)
+	}
+	return oldR, oldS, oldT
+}
+
+// ModInverse returns a^-1 mod m for a positive m. It returns
+// ErrNotInvertible when gcd(a, m) != 1.
+func ModInverse(a, m *big.Int) (*big.Int, error) {
+	if m.Sign() <= 0 {
+		return nil, ErrModulus
+	}
+
+	g, x, _ := ExtendedGCD(new(big.Int).Mod(a, m), m)
+	if g.Cmp(one) != 0 {
+		return nil, ErrNotInvertible
+	}
+	return x.Mod(x, m), nil
+}
+
+// Jacobi returns the Jacobi symbol (a/n) for an odd positive n
+func Jacobi(a, n *big.Int) (int, error) {
+	if n.Sign() <= 0 || n.Bit(0) == 0 {
+		return 0, ErrJacobiModulus
+	}
+	return jacobi(a, n), nil
+}
+
+// jacobi is Jacobi for callers that already know n is odd and positive
+func jacobi(a, n *big.Int) int {
+	a = new(big.Int).Mod(a, n)
+	n = new(big.Int).Set(n)
+	result := 1
+	for a.Sign() != 0 {
+		// Pull out factors of two: (2/n) = -1 when n = 3 or 5 mod 8
+		z := a.TrailingZeroBits()
+		a.Rsh(a, z)
+		if r := n.Bits()[0] & 7; z%2 == 1 && (r == 3 || r == 5) {
+			result = -result
+		}
+
+		// Quadratic reciprocity: flip the sign when both are 3 mod 4
+		a, n = n, a
+		if a.Bits()[0]&3 == 3 && n.Bits()[0]&3 == 3 {
+			result = -result
+		}
+		a.Mod(a, n)
+	}
+
+	if n.Cmp(one) != 0 {
+		return 0
+	}
+	return result
+}
+
+// Legendre returns the Legendre symbol (a/p) for an odd prime p: 1 if a is
+// a nonzero square mod p, -1 if it is not a square and 0 if p divides a.
+// Only the oddness of p is checked.
+func Legendre(a, p *big.Int) (int, error) {
+	return Jacobi(a, p)
+}
@@ -0,0 +1,152 @@
+// Package primes implements probabilistic primality tests and the number
+// theory they are built on.
+//
+// Every test satisfies PrimalityTest: it repeats a randomized round until a
+// composite would have slipped through every round with probability at
+// most 1 - minProbability.
+package primes
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+)
+
+// ErrProbability is returned when minProbability is not in [0.5, 1)
+var ErrProbability = errors.New("primes: minProbability must be in [0.5, 1)")
+
+// DefaultProbability is the minimum probability that a generated prime is
+// really prime, used by key generation when given no explicit value
+const DefaultProbability = 1 - 1e-15
+
+var (
+	one = big.NewInt(1)
+	two = big.NewInt(2)
+)
+
+// PrimalityTest decides whether n is prime. A prime is always accepted; a
+// composite is accepted with probability at most 1 - minProbability, which
+// must be in [0.5, 1). An error means no decision was made.
+type PrimalityTest interface {
+	IsProbablyPrime(n *big.Int, minProbability float64) (bool, error)
+}
+
+// witnessFunc reports whether a passes one round for the odd n >= 5; false
+// proves that n is composite
+type witnessFunc func(n, a *big.Int) bool
+
+// Rounds returns how many rounds of a test that accepts a composite with
+// probability at most errPerRound are needed to reach minProbability
+func Rounds(minProbability, errPerRound float64) (int, error) {
+	if err := checkProbability(minProbability); err != nil {
+		return 0, err
+	}
+	return max(1, int(math.Ceil(math.Log(1-minProbability)/math.Log(errPerRound)))), nil
+}
+
+// checkProbability returns ErrProbability unless minProbability is in [0.5, 1)
+func checkProbability(minProbability float64) error {
+	if !(minProbability >= 0.5 && minProbability < 1) {
+		return ErrProbability
+	}
+	return nil
+}
+
+// runTest handles the trivial cases and then runs the rounds with random
+// bases in [2, n-2]
+func runTest(n *big.Int, minProbability, errPerRound float64, witness witnessFunc) (bool, error) {
+	rounds, err := Rounds(minProbability, errPerRound)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case n.Cmp(two) < 0:
+		return false, nil
+	case n.Cmp(big.NewInt(4)) < 0:
+		return true, nil
+	case n.Bit(0) == 0:
+		return false, nil
+	}
+
+	// rand.Int draws from [0, n-3), shifted to [2, n-2]
+	limit := new(big.Int).Sub(n, big.NewInt(3))
+	for i := 0; i < rounds; i++ {
+		a, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return false, err
+		}
+		a.Add(a, two)
+
+		if !witness(n, a) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Fermat is the Fermat test: a prime n satisfies a^(n-1) = 1 mod n for
+// every a. Carmichael numbers pass it for every base coprime to them, so
+// its error bound of 1/2 per round only holds for other composites.
+type Fermat struct{}
+
+// IsProbablyPrime implements PrimalityTest
+func (Fermat) IsProbablyPrime(n *big.Int, minProbability float64) (bool, error) {
+	return runTest(n, minProbability, 0.5, func(n, a *big.Int) bool {
+		nMinus1 := new(big.Int).Sub(n, one)
+		return modPow(a, nMinus1, n).Cmp(one) == 0
+	})
+}
+
+// SolovayStrassen is the Solovay-Strassen test: a prime n satisfies
+// a^((n-1)/2) = (a/n) mod n, where (a/n) is the Jacobi symbol. A composite
+// passes a round with probability at most 1/2.
+type SolovayStrassen struct{}
+
+// IsProbablyPrime implements PrimalityTest
+func (SolovayStrassen) IsProbablyPrime(n *big.Int, minProbability float64) (bool, error) {
+	return runTest(n, minProbability, 0.5, func(n, a *big.Int) bool {
+		j := jacobi(a, n)
+		if j == 0 {
+			return false
+		}
+
+		half := new(big.Int).Rsh(n, 1)
+		x := modPow(a, half, n)
+
+		want := big.NewInt(int64(j))
+		want.Mod(want, n)
+		return x.Cmp(want) == 0
+	})
+}
+
+// MillerRabin is the Miller-Rabin test: writing n-1 = 2^s d with d odd, a
+// prime n has a^d = 1 or a^(2^r d) = -1 mod n for some r < s. A composite
+// passes a round with probability at most 1/4.
+type MillerRabin struct{}
+
+// IsProbablyPrime implements PrimalityTest
+func (MillerRabin) IsProbablyPrime(n *big.Int, minProbability float64) (bool, error) {
+	return runTest(n, minProbability, 0.25, func(n, a *big.Int) bool {
+		nMinus1 := new(big.Int).Sub(n, one)
+		s := nMinus1.TrailingZeroBits()
+		d := new(big.Int).Rsh(nMinus1, s)
+
+		x := modPow(a, d, n)
+		if x.Cmp(one) == 0 || x.Cmp(nMinus1) == 0 {
+			return true
+		}
+		for r := uint(1); r < s; r++ {
+			x.Mul(x, x)
+			x.Mod(x, n)
+			if x.Cmp(nMinus1) == 0 {
+				return true
+			}
+			if x.Cmp(one) == 0 {
+				return false
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,215 @@
+package primes_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"CryptographyCW/pkg/crypto/primes"
+)
+
+// minProbability leaves a composite about a 1e-9 chance per test of
+// slipping through, so the table below is effectively deterministic
+const minProbability = 1 - 1e-9
+
+var tests = map[string]primes.PrimalityTest{
+	"Fermat":          primes.Fermat{},
+	"SolovayStrassen": primes.SolovayStrassen{},
+	"MillerRabin":     primes.MillerRabin{},
+}
+
+func mersenne(p uint) *big.Int {
+	n := new(big.Int).Lsh(big.NewInt(1), p)
+	return n.Sub(n, big.NewInt(1))
+}
+
+// chernick returns the Carmichael number (6k+1)(12k+1)(18k+1); k =
+// 1000000001121 makes all three factors prime, and they are so large that
+// a random base shares a factor with n with negligible probability
+func chernick(k int64) *big.Int {
+	n := big.NewInt(6*k + 1)
+	n.Mul(n, big.NewInt(12*k+1))
+	return n.Mul(n, big.NewInt(18*k+1))
+}
+
+func TestPrimalityTests(t *testing.T) {
+	cases := []struct {
+		n     *big.Int
+		prime bool
+	}{
+		{big.NewInt(0), false},
+		{big.NewInt(1), false},
+		{big.NewInt(2), true},
+		{big.NewInt(3), true},
+		{big.NewInt(4), false},
+		{big.NewInt(5), true},
+		{big.NewInt(9), false},
+		{big.NewInt(97), true},
+		{big.NewInt(341), false}, // base-2 Fermat pseudoprime, 11 * 31
+		{big.NewInt(7919), true},
+		{big.NewInt(1000003), true},
+		{big.NewInt(1000001), false}, // 101 * 9901
+		{mersenne(61), true},
+		{mersenne(89), true},
+		{mersenne(127), true},
+		{mersenne(67), false}, // 193707721 * 761838257287
+	}
+
+	for name, test := range tests {
+		for _, c := range cases {
+			got, err := test.IsProbablyPrime(c.n, minProbability)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.prime {
+				t.Errorf("%s(%v) = %v, want %v", name, c.n, got, c.prime)
+			}
+		}
+	}
+}
+
+// TestCarmichael shows Fermat's weakness: Carmichael numbers pass it for
+// every coprime base, while Solovay-Strassen and Miller-Rabin reject them
+func TestCarmichael(t *testing.T) {
+	carmichael := []*big.Int{
+		big.NewInt(561),
+		big.NewInt(1105),
+		big.NewInt(1729),
+		big.NewInt(2465),
+		big.NewInt(2821),
+		big.NewInt(6601),
+		big.NewInt(8911),
+		big.NewInt(41041),
+		big.NewInt(825265),
+		chernick(1000000001121),
+	}
+
+	for _, n := range carmichael {
+		for _, name := range []string{"SolovayStrassen", "MillerRabin"} {
+			got, err := tests[name].IsProbablyPrime(n, minProbability)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got {
+				t.Errorf("%s accepted Carmichael number %v", name, n)
+			}
+		}
+	}
+
+	// The small ones have small factors a random base often hits, but the
+	// large one is all but certain to fool Fermat
+	n := chernick(1000000001121)
+	if got, err := (primes.Fermat{}).IsProbablyPrime(n, minProbability); err != nil || !got {
+		t.Errorf("Fermat(%v) = %v, %v, want true", n, got, err)
+	}
+}
+
+func TestRounds(t *testing.T) {
+	cases := []struct {
+		minProbability, errPerRound float64
+		want                        int
+	}{
+		{0.5, 0.5, 1},
+		{0.75, 0.5, 2},
+		{0.99, 0.5, 7},
+		{0.99, 0.25, 4},
+		{0.999, 0.25, 5},
+	}
+
+	for _, c := range cases {
+		got, err := primes.Rounds(c.minProbability, c.errPerRound)
+		if err != nil || got != c.want {
+			t.Errorf("Rounds(%v, %v) = %d, %v, want %d", c.minProbability, c.errPerRound, got, err, c.want)
+		}
+	}
+}
+
+func TestInvalidProbability(t *testing.T) {
+	for _, p := range []float64{0, 0.49, 1, 1.5, math.NaN()} {
+		if _, err := primes.Rounds(p, 0.5); !errors.Is(err, primes.ErrProbability) {
+			t.Errorf("Rounds(%v): err = %v", p, err)
+		}
+		for name, test := range tests {
+			if _, err := test.IsProbablyPrime(big.NewInt(97), p); !errors.Is(err, primes.ErrProbability) {
+				t.Errorf("%s at %v: err = %v", name, p, err)
+			}
+		}
+		if _, err := primes.Random(2, primes.MillerRabin{}, p); !errors.Is(err, primes.ErrProbability) {
+			t.Errorf("Random at %v: err = %v", p, err)
+		}
+		if _, _, err := primes.Safe(256, primes.MillerRabin{}, p); !errors.Is(err, primes.ErrProbability) {
+			t.Errorf("Safe at %v: err = %v", p, err)
+		}
+		if _, err := primes.Strong(256, primes.MillerRabin{}, p); !errors.Is(err, primes.ErrProbability) {
+			t.Errorf("Strong at %v: err = %v", p, err)
+		}
+	}
+}
+
+func TestNumberTheory(t *testing.T) {
+	for a := int64(-20); a <= 20; a++ {
+		for n := int64(1); n <= 51; n += 2 {
+			A, N := big.NewInt(a), big.NewInt(n)
+			got, err := primes.Jacobi(A, N)
+			if want := big.Jacobi(A, N); err != nil || got != want {
+				t.Errorf("Jacobi(%d, %d) = %d, %v, want %d", a, n, got, err, want)
+			}
+		}
+	}
+
+	m := mersenne(89)
+	base, exp := big.NewInt(123456789), mersenne(61)
+	got, err := primes.ModPow(base, exp, m)
+	if want := new(big.Int).Exp(base, exp, m); err != nil || got.Cmp(want) != 0 {
+		t.Errorf("ModPow = %v, %v, want %v", got, err, want)
+	}
+
+	a, b := big.NewInt(240), big.NewInt(46)
+	g, x, y := primes.ExtendedGCD(a, b)
+	lhs := new(big.Int).Add(new(big.Int).Mul(a, x), new(big.Int).Mul(b, y))
+	if g.Int64() != 2 || lhs.Cmp(g) != 0 {
+		t.Errorf("ExtendedGCD(240, 46) = %v, %v, %v", g, x, y)
+	}
+
+	if inv, err := primes.ModInverse(big.NewInt(3), big.NewInt(11)); err != nil || inv.Int64() != 4 {
+		t.Errorf("ModInverse(3, 11) = %v, %v, want 4", inv, err)
+	}
+	if inv, err := primes.ModInverse(big.NewInt(-8), big.NewInt(11)); err != nil || inv.Int64() != 4 {
+		t.Errorf("ModInverse(-8, 11) = %v, %v, want 4", inv, err)
+	}
+}
+
+func TestNumberTheoryErrors(t *testing.T) {
+	n := big.NewInt
+	tests := []struct {
+		name string
+		err  error
+		fn   func() error
+	}{
+		{"ModPow m = 0", primes.ErrModulus, func() error { _, err := primes.ModPow(n(2), n(3), n(0)); return err }},
+		{"ModPow m < 0", primes.ErrModulus, func() error { _, err := primes.ModPow(n(2), n(3), n(-7)); return err }},
+		{"ModPow exp < 0", primes.ErrNegativeExponent, func() error { _, err := primes.ModPow(n(2), n(-1), n(7)); return err }},
+		{"ModInverse gcd 3", primes.ErrNotInvertible, func() error { _, err := primes.ModInverse(n(6), n(9)); return err }},
+		{"ModInverse of 0", primes.ErrNotInvertible, func() error { _, err := primes.ModInverse(n(0), n(9)); return err }},
+		{"ModInverse m = 0", primes.ErrModulus, func() error { _, err := primes.ModInverse(n(3), n(0)); return err }},
+		{"ModInverse m < 0", primes.ErrModulus, func() error { _, err := primes.ModInverse(n(3), n(-11)); return err }},
+		{"Jacobi n even", primes.ErrJacobiModulus, func() error { _, err := primes.Jacobi(n(3), n(8)); return err }},
+		{"Jacobi n = 0", primes.ErrJacobiModulus, func() error { _, err := primes.Jacobi(n(3), n(0)); return err }},
+		{"Jacobi n < 0", primes.ErrJacobiModulus, func() error { _, err := primes.Jacobi(n(3), n(-7)); return err }},
+		{"Legendre p = 2", primes.ErrJacobiModulus, func() error { _, err := primes.Legendre(n(3), n(2)); return err }},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic: %v", tt.name, r)
+				}
+			}()
+			if err := tt.fn(); !errors.Is(err, tt.err) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			}
+		}()
+	}
+}
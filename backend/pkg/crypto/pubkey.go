@@ -0,0 +1,21 @@
+package crypto
+
+import (
+	"fmt"
+
+	"CryptographyCW/pkg/crypto/elgamal"
+	"CryptographyCW/pkg/crypto/rabin"
+)
+
+// ParsePublicKey rebuilds an ElGamal or Rabin public key from the named
+// integers returned by its Components method
+func ParsePublicKey(algorithm string, components map[string][]byte) (interface{}, error) {
+	switch algorithm {
+	case "ElGamal":
+		return elgamal.ParsePublicKey(components)
+	case "Rabin":
+		return rabin.ParsePublicKey(components)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
@@ -0,0 +1,224 @@
+// Package rabin implements the Rabin cryptosystem on top of math/big.
+//
+// A ciphertext is m^2 mod N, which has four square roots. Plaintexts are
+// padded with random bytes to one byte short of the modulus, so m^2 always
+// wraps mod N, and carry redundancy, a marker byte and a truncated SHA-256
+// digest, so decryption can tell the right root from the other three.
+package rabin
+
+import (
+	"CryptographyCW/pkg/crypto/primes"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// redundancySize is the length of the digest appended to each plaintext
+const redundancySize = 8
+
+// overhead is the marker, delimiter and digest around each plaintext, plus
+// the byte that keeps the padded plaintext below N
+const overhead = 3 + redundancySize
+
+var (
+	ErrKeySize        = errors.New("rabin: key must be at least 256 bits and even")
+	ErrMessageTooLong = errors.New("rabin: message too long for the key size")
+	ErrDecryption     = errors.New("rabin: decryption error")
+	ErrInvalidKey     = errors.New("rabin: invalid key")
+)
+
+var (
+	one   = big.NewInt(1)
+	three = big.NewInt(3)
+	four  = big.NewInt(4)
+)
+
+// PublicKey is a Rabin public key
+type PublicKey struct {
+	N *big.Int
+}
+
+// PrivateKey is a Rabin private key
+type PrivateKey struct {
+	PublicKey
+	P, Q *big.Int // prime factors of N, both 3 mod 4
+}
+
+// GenerateKey creates a key with a modulus of the given number of bits from
+// two strong primes that are 3 mod 4, so square roots mod each of them are
+// a single exponentiation. Primes are accepted by test with at least
+// minProbability; a nil test means primes.MillerRabin and a zero
+// probability means primes.DefaultProbability.
+func GenerateKey(bits int, test primes.PrimalityTest, minProbability float64) (*PrivateKey, error) {
+	if bits < 256 || bits%2 != 0 {
+		return nil, ErrKeySize
+	}
+	if test == nil {
+		test = primes.MillerRabin{}
+	}
+	if minProbability == 0 {
+		minProbability = primes.DefaultProbability
+	}
+
+	prime := func() (*big.Int, error) {
+		for {
+			p, err := primes.Strong(bits/2, test, minProbability)
+			if err != nil {
+				return nil, err
+			}
+			if new(big.Int).Mod(p, four).Cmp(three) == 0 {
+				return p, nil
+			}
+		}
+	}
+
+	for {
+		p, err := prime()
+		if err != nil {
+			return nil, err
+		}
+		q, err := prime()
+		if err != nil {
+			return nil, err
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || n.BitLen() != bits {
+			continue
+		}
+		return &PrivateKey{PublicKey: PublicKey{N: n}, P: p, Q: q}, nil
+	}
+}
+
+// Size returns the modulus length in bytes
+func (pub *PublicKey) Size() int {
+	return (pub.N.BitLen() + 7) / 8
+}
+
+// Components returns the public key as named big-endian integers, the
+// form in which it travels in public_key messages
+func (pub *PublicKey) Components() map[string][]byte {
+	return map[string][]byte{"n": pub.N.Bytes()}
+}
+
+// ParsePublicKey rebuilds a public key from Components
+func ParsePublicKey(c map[string][]byte) (*PublicKey, error) {
+	n := new(big.Int).SetBytes(c["n"])
+	if n.BitLen() < 256 || n.Bit(0) == 0 {
+		return nil, ErrInvalidKey
+	}
+	return &PublicKey{N: n}, nil
+}
+
+// redundancy returns the digest appended to data
+func redundancy(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:redundancySize]
+}
+
+// Encrypt encrypts msg, which can be at most Size() - 11 bytes long. The
+// plaintext is randomly padded, so encrypting the same message twice gives
+// different ciphertexts.
+func Encrypt(pub *PublicKey, msg []byte) ([]byte, error) {
+	k := pub.Size()
+	if len(msg) > k-overhead {
+		return nil, ErrMessageTooLong
+	}
+
+	// em = 0x01 || PS || 0x00 || msg || H(0x01 || PS || 0x00 || msg)[:8],
+	// k-1 bytes long with PS random and nonzero. Its leading 0x01 makes
+	// m >= 2^(8(k-2)), so m^2 >= N.
+	em := make([]byte, 0, k-1)
+	em = append(em, 0x01)
+	ps, err := nonzeroRandom(k - overhead - len(msg))
+	if err != nil {
+		return nil, err
+	}
+	em = append(em, ps...)
+	em = append(em, 0x00)
+	em = append(em, msg...)
+	em = append(em, redundancy(em)...)
+
+	m := new(big.Int).SetBytes(em)
+	c := m.Mul(m, m)
+	c.Mod(c, pub.N)
+	return c.FillBytes(make([]byte, k)), nil
+}
+
+// nonzeroRandom returns n random bytes, none of them zero
+func nonzeroRandom(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	for i := range b {
+		for b[i] == 0 {
+			if _, err := rand.Read(b[i : i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt, returning the only
+// square root that carries valid redundancy
+func Decrypt(priv *PrivateKey, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) != priv.Size() {
+		return nil, ErrDecryption
+	}
+	c := new(big.Int).SetBytes(ciphertext)
+	if c.Cmp(priv.N) >= 0 {
+		return nil, ErrDecryption
+	}
+
+	// For a prime p = 3 mod 4 the square roots of c are +-c^((p+1)/4)
+	mp := new(big.Int).Exp(c, new(big.Int).Rsh(new(big.Int).Add(priv.P, one), 2), priv.P)
+	mq := new(big.Int).Exp(c, new(big.Int).Rsh(new(big.Int).Add(priv.Q, one), 2), priv.Q)
+
+	// Combine with the CRT using yp p + yq q = 1
+	_, yp, yq := primes.ExtendedGCD(priv.P, priv.Q)
+	a := new(big.Int).Mul(yp, priv.P)
+	a.Mul(a, mq)
+	b := new(big.Int).Mul(yq, priv.Q)
+	b.Mul(b, mp)
+
+	r := new(big.Int).Add(a, b)
+	r.Mod(r, priv.N)
+	s := new(big.Int).Sub(a, b)
+	s.Mod(s, priv.N)
+
+	var found []byte
+	matches := 0
+	for _, root := range []*big.Int{r, new(big.Int).Sub(priv.N, r), s, new(big.Int).Sub(priv.N, s)} {
+		if msg, ok := unpad(root.Bytes(), priv.Size()); ok {
+			found = msg
+			matches++
+		}
+	}
+
+	if matches != 1 {
+		return nil, ErrDecryption
+	}
+	return found, nil
+}
+
+// unpad checks the length, marker, padding and digest of a candidate
+// plaintext for a k-byte modulus and strips everything but the message
+func unpad(em []byte, k int) ([]byte, bool) {
+	if len(em) != k-1 || em[0] != 0x01 {
+		return nil, false
+	}
+
+	body := em[:len(em)-redundancySize]
+	if !bytes.Equal(em[len(em)-redundancySize:], redundancy(body)) {
+		return nil, false
+	}
+	sep := bytes.IndexByte(body[1:], 0x00)
+	if sep < 0 {
+		return nil, false
+	}
+	return body[1+sep+1:], true
+}
@@ -0,0 +1,203 @@
+package rabin_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"CryptographyCW/pkg/crypto/rabin"
+)
+
+// square returns em^2 mod N as a ciphertext, for plaintexts Encrypt
+// would not produce
+func square(pub *rabin.PublicKey, em []byte) []byte {
+	m := new(big.Int).SetBytes(em)
+	c := m.Mul(m, m)
+	c.Mod(c, pub.N)
+	return c.FillBytes(make([]byte, pub.Size()))
+}
+
+func TestGenerateKey(t *testing.T) {
+	priv, err := rabin.GenerateKey(512, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.N.BitLen() != 512 {
+		t.Errorf("N has %d bits", priv.N.BitLen())
+	}
+	if new(big.Int).Mul(priv.P, priv.Q).Cmp(priv.N) != 0 {
+		t.Error("N != PQ")
+	}
+	for _, p := range []*big.Int{priv.P, priv.Q} {
+		if !p.ProbablyPrime(20) || p.Bit(0) != 1 || p.Bit(1) != 1 {
+			t.Errorf("%v is not a prime that is 3 mod 4", p)
+		}
+	}
+
+	for _, bits := range []int{0, 128, 255, 257} {
+		if _, err := rabin.GenerateKey(bits, nil, 0); !errors.Is(err, rabin.ErrKeySize) {
+			t.Errorf("%d bits: err = %v", bits, err)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	priv, err := rabin.GenerateKey(256, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxLen := priv.Size() - 11
+
+	// The longest messages use every byte the modulus allows
+	messages := [][]byte{
+		{},
+		{0},
+		{0, 0, 0, 1},
+		[]byte("hello"),
+		bytes.Repeat([]byte{0xFF}, maxLen),
+		bytes.Repeat([]byte{0x01}, maxLen),
+		make([]byte, maxLen),
+	}
+	for _, msg := range messages {
+		c, err := rabin.Encrypt(&priv.PublicKey, msg)
+		if err != nil {
+			t.Fatalf("%x: %v", msg, err)
+		}
+		got, err := rabin.Decrypt(priv, c)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("%x: round trip gave %x, %v", msg, got, err)
+		}
+	}
+
+	if _, err := rabin.Encrypt(&priv.PublicKey, make([]byte, maxLen+1)); !errors.Is(err, rabin.ErrMessageTooLong) {
+		t.Errorf("long message: err = %v", err)
+	}
+}
+
+// TestEncryptWraps checks that even the empty message is padded far enough
+// for m^2 to wrap mod N, so c is not a plain integer square, and that
+// encryption is randomised
+func TestEncryptWraps(t *testing.T) {
+	priv, err := rabin.GenerateKey(256, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c1, err := rabin.Encrypt(&priv.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := new(big.Int).SetBytes(c1)
+	if root := new(big.Int).Sqrt(c); root.Mul(root, root).Cmp(c) == 0 {
+		t.Errorf("ciphertext %x is a perfect square, so m^2 < N", c1)
+	}
+
+	c2, err := rabin.Encrypt(&priv.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(c1, c2) {
+		t.Error("encrypting the same message twice gave the same ciphertext")
+	}
+}
+
+// padded builds the plaintext Encrypt would for msg with a fixed padding
+// byte, optionally changed by tweak before the digest is appended
+func padded(pub *rabin.PublicKey, msg []byte, tweak func([]byte) []byte) []byte {
+	em := []byte{0x01}
+	em = append(em, bytes.Repeat([]byte{0xAA}, pub.Size()-11-len(msg))...)
+	em = append(em, 0x00)
+	em = append(em, msg...)
+	if tweak != nil {
+		em = tweak(em)
+	}
+	sum := sha256.Sum256(em)
+	return append(em, sum[:8]...)
+}
+
+// TestDecryptRedundancy squares plaintexts whose redundancy is almost right:
+// none of the four roots may be accepted
+func TestDecryptRedundancy(t *testing.T) {
+	priv, err := rabin.GenerateKey(256, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	msg := []byte("hello")
+	sum := sha256.Sum256(msg)
+
+	wrongDigest := padded(pub, msg, nil)
+	wrongDigest[len(wrongDigest)-1] ^= 0x01
+
+	tests := map[string][]byte{
+		"marker 0x02":  padded(pub, msg, func(em []byte) []byte { em[0] = 0x02; return em }),
+		"digest bit":   wrongDigest,
+		"short":        padded(pub, msg, func(em []byte) []byte { return append(em[:1], em[2:]...) }),
+		"no delimiter": padded(pub, msg, func(em []byte) []byte { em[len(em)-len(msg)-1] = 0xAA; return em }),
+		"unpadded":     append(append([]byte{0x01}, msg...), sum[:8]...),
+		"small square": {0x02},
+	}
+	for name, em := range tests {
+		if _, err := rabin.Decrypt(priv, square(pub, em)); !errors.Is(err, rabin.ErrDecryption) {
+			t.Errorf("%s: err = %v, want ErrDecryption", name, err)
+		}
+	}
+
+	// The same checks accept a well-formed plaintext built by hand
+	got, err := rabin.Decrypt(priv, square(pub, padded(pub, msg, nil)))
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("hand-built plaintext: %x, %v", got, err)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	priv, err := rabin.GenerateKey(256, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := rabin.Encrypt(&priv.PublicKey, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, ciphertext := range map[string][]byte{
+		"truncated": c[1:],
+		"extended":  append(bytes.Clone(c), 0),
+		"zero":      make([]byte, priv.Size()),
+		"N":         priv.N.FillBytes(make([]byte, priv.Size())),
+	} {
+		if _, err := rabin.Decrypt(priv, ciphertext); !errors.Is(err, rabin.ErrDecryption) {
+			t.Errorf("%s: err = %v, want ErrDecryption", name, err)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	priv, err := rabin.GenerateKey(256, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &priv.PublicKey
+	got, err := rabin.ParsePublicKey(pub.Components())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.N.Cmp(pub.N) != 0 {
+		t.Error("Components round trip changed the key")
+	}
+
+	tests := map[string]map[string][]byte{
+		"empty":   {},
+		"n empty": {"n": nil},
+		"n even":  {"n": new(big.Int).Add(pub.N, big.NewInt(1)).Bytes()},
+		"n small": {"n": big.NewInt(1000003).Bytes()},
+		"wrong":   {"p": pub.N.Bytes()},
+	}
+	for name, c := range tests {
+		if _, err := rabin.ParsePublicKey(c); !errors.Is(err, rabin.ErrInvalidKey) {
+			t.Errorf("%s: err = %v, want ErrInvalidKey", name, err)
+		}
+	}
+}
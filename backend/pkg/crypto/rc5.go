@@ -3,244 +3,214 @@ package crypto
 
 import "errors"
 
+// Magic constants for the RC5 key schedule, per word size
 const (
+	P16 = 0xB7E1
+	Q16 = 0x9E37
 	P32 = 0xB7E15163
 	Q32 = 0x9E3779B9
+	P64 = 0xB7E151628AED2A6B
+	Q64 = 0x9E3779B97F4A7C15
 )
 
-// RC5 represents an RC5 cipher instance
+// DefaultRC5Rounds is the number of rounds used when an algorithm name does not specify one
+const DefaultRC5Rounds = 12
+
+// RC5 represents an RC5-w/r/b cipher instance
 type RC5 struct {
-	rounds int
-	S      []uint32
+	blockModes
+	wordSize uint // word size in bits: 16, 32 or 64
+	rounds   int
+	S        []uint64
 }
 
-// New creates a new RC5 cipher instance
+// New creates a new RC5-32 cipher instance
 func New(rounds int, key []byte) (*RC5, error) {
-	if rounds < 8 || rounds > 32 {
-		return nil, errors.New("rc5: number of rounds must be between 8 and 32")
+	return NewRC5(32, rounds, key)
+}
+
+// NewRC5 creates a new RC5-w/r/b cipher instance with w-bit words (16, 32 or 64),
+// r rounds (0-255) and a key of b bytes (0-255)
+func NewRC5(wordSize, rounds int, key []byte) (*RC5, error) {
+	if wordSize != 16 && wordSize != 32 && wordSize != 64 {
+		return nil, errors.New("rc5: word size must be 16, 32 or 64 bits")
+	}
+	if rounds < 0 || rounds > 255 {
+		return nil, errors.New("rc5: number of rounds must be between 0 and 255")
+	}
+	if len(key) > 255 {
+		return nil, errors.New("rc5: key must be at most 255 bytes")
 	}
 
 	c := &RC5{
-		rounds: rounds,
-		S:      make([]uint32, 2*(rounds+1)),
+		wordSize: uint(wordSize),
+		rounds:   rounds,
+		S:        make([]uint64, 2*(rounds+1)),
 	}
 
+	c.blockModes = blockModes{c}
 	c.expandKey(key)
 	return c, nil
 }
 
+func init() {
+	// "RC5" alone is RC5-32; a "/r" suffix sets the number of rounds
+	for _, v := range []struct {
+		name     string
+		wordSize int
+	}{{"RC5", 32}, {"RC5-16", 16}, {"RC5-32", 32}, {"RC5-64", 64}} {
+		wordSize := v.wordSize
+		Register(Algorithm{
+			Name:      v.name,
+			BlockSize: 2 * wordSize / 8,
+			KeySizes:  KeySizes{Min: 0, Max: 255, Step: 1},
+			KeySize:   16,
+			Parameter: "rounds",
+			New: func(key []byte, param string) (Cipher, error) {
+				rounds, err := parseRounds(param, DefaultRC5Rounds)
+				if err != nil {
+					return nil, err
+				}
+				return NewRC5(wordSize, rounds, key)
+			},
+		})
+	}
+}
+
 // expandKey initializes the key schedule
 func (c *RC5) expandKey(key []byte) {
-	// Convert key to uint32 words
-	L := bytesToWords(key)
+	expandRC5Key(c.S, key, c.wordSize)
+}
+
+// expandRC5Key fills S with the RC5 key schedule for w-bit words.
+// RC6 uses the same schedule with a longer table.
+func expandRC5Key(S []uint64, key []byte, w uint) {
+	mask := wordMask(w)
+
+	// Convert key to words
+	L := bytesToWords(key, int(w/8))
 
 	// Initialize magic constants
-	c.S[0] = P32
-	for i := 1; i < len(c.S); i++ {
-		c.S[i] = c.S[i-1] + Q32
+	var P, Q uint64
+	switch w {
+	case 16:
+		P, Q = P16, Q16
+	case 32:
+		P, Q = P32, Q32
+	default:
+		P, Q = P64, Q64
+	}
+	S[0] = P
+	for i := 1; i < len(S); i++ {
+		S[i] = (S[i-1] + Q) & mask
 	}
 
 	// Mix in the secret key
-	i, j, A, B := 0, 0, uint32(0), uint32(0)
-	m := 3 * maxInt(len(c.S), len(L))
+	i, j, A, B := 0, 0, uint64(0), uint64(0)
+	m := 3 * maxInt(len(S), len(L))
 
 	for k := 0; k < m; k++ {
-		A = rotateLeft(c.S[i]+A+B, 3)
-		c.S[i] = A
-		B = rotateLeft(L[j]+A+B, A+B)
+		A = rotateLeft(S[i]+A+B, 3, w)
+		S[i] = A
+		B = rotateLeft(L[j]+A+B, A+B, w)
 		L[j] = B
 
-		i = (i + 1) % len(c.S)
+		i = (i + 1) % len(S)
 		j = (j + 1) % len(L)
 	}
 }
 
-// Encrypt encrypts a 64-bit block
-func (c *RC5) Encrypt(block []byte) []byte {
-	if len(block) != 8 {
-		panic("rc5: block size must be 8 bytes")
-	}
+// BlockSize returns the cipher's block size in bytes, two words
+func (c *RC5) BlockSize() int {
+	return int(c.wordSize / 4)
+}
 
-	A := bytesToUint32(block[0:4])
-	B := bytesToUint32(block[4:8])
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (c *RC5) encryptBlock(dst, src []byte) {
+	bs := c.BlockSize()
+	w, mask := c.wordSize, wordMask(c.wordSize)
+	A := bytesToWord(src[:bs/2])
+	B := bytesToWord(src[bs/2 : bs])
 
-	A += c.S[0]
-	B += c.S[1]
+	A = (A + c.S[0]) & mask
+	B = (B + c.S[1]) & mask
 
 	for i := 1; i <= c.rounds; i++ {
-		A = rotateLeft(A^B, B) + c.S[2*i]
-		B = rotateLeft(B^A, A) + c.S[2*i+1]
+		A = (rotateLeft(A^B, B, w) + c.S[2*i]) & mask
+		B = (rotateLeft(B^A, A, w) + c.S[2*i+1]) & mask
 	}
 
-	out := make([]byte, 8)
-	uint32ToBytes(A, out[0:4])
-	uint32ToBytes(B, out[4:8])
-
-	return out
+	wordToBytes(A, dst[:bs/2])
+	wordToBytes(B, dst[bs/2:bs])
 }
 
-// Decrypt decrypts a 64-bit block
-func (c *RC5) Decrypt(block []byte) []byte {
-	if len(block) != 8 {
-		panic("rc5: block size must be 8 bytes")
-	}
-
-	A := bytesToUint32(block[0:4])
-	B := bytesToUint32(block[4:8])
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (c *RC5) decryptBlock(dst, src []byte) {
+	bs := c.BlockSize()
+	w, mask := c.wordSize, wordMask(c.wordSize)
+	A := bytesToWord(src[:bs/2])
+	B := bytesToWord(src[bs/2 : bs])
 
 	for i := c.rounds; i >= 1; i-- {
-		B = rotateRight(B-c.S[2*i+1], A) ^ A
-		A = rotateRight(A-c.S[2*i], B) ^ B
+		B = rotateRight(B-c.S[2*i+1], A, w) ^ A
+		A = rotateRight(A-c.S[2*i], B, w) ^ B
 	}
 
-	B -= c.S[1]
-	A -= c.S[0]
-
-	out := make([]byte, 8)
-	uint32ToBytes(A, out[0:4])
-	uint32ToBytes(B, out[4:8])
+	B = (B - c.S[1]) & mask
+	A = (A - c.S[0]) & mask
 
-	return out
+	wordToBytes(A, dst[:bs/2])
+	wordToBytes(B, dst[bs/2:bs])
 }
 
-// EncryptCBC encrypts data using CBC mode
-func (c *RC5) EncryptCBC(data []byte, iv []byte) []byte {
-	if len(iv) != 8 {
-		panic("rc5: IV must be 8 bytes")
-	}
-
-	// Pad data to block size if necessary
-	padLen := 8 - (len(data) % 8)
-	paddedData := make([]byte, len(data)+padLen)
-	copy(paddedData, data)
-	// PKCS7 padding
-	for i := len(data); i < len(paddedData); i++ {
-		paddedData[i] = byte(padLen)
-	}
-
-	// Initialize previous block with IV
-	prev := make([]byte, 8)
-	copy(prev, iv)
-
-	// Process each block
-	ciphertext := make([]byte, len(paddedData))
-	for i := 0; i < len(paddedData); i += 8 {
-		// XOR with previous ciphertext block (or IV for first block)
-		block := make([]byte, 8)
-		for j := 0; j < 8; j++ {
-			block[j] = paddedData[i+j] ^ prev[j]
-		}
-
-		// Encrypt block
-		encrypted := c.Encrypt(block)
-
-		// Copy to output and save for next iteration
-		copy(ciphertext[i:], encrypted)
-		copy(prev, encrypted)
-	}
+// Helper functions
 
-	return ciphertext
+// wordMask returns a mask of the low w bits
+func wordMask(w uint) uint64 {
+	return ^uint64(0) >> (64 - w)
 }
 
-// DecryptCBC decrypts data using CBC mode
-func (c *RC5) DecryptCBC(ciphertext []byte, iv []byte) []byte {
-	if len(iv) != 8 {
-		panic("rc5: IV must be 8 bytes")
-	}
-	if len(ciphertext)%8 != 0 {
-		panic("rc5: ciphertext is not a multiple of block size")
-	}
-
-	// Initialize previous block with IV
-	prev := make([]byte, 8)
-	copy(prev, iv)
-
-	// Process each block
-	plaintext := make([]byte, len(ciphertext))
-	for i := 0; i < len(ciphertext); i += 8 {
-		// Save current ciphertext block for next iteration
-		current := make([]byte, 8)
-		copy(current, ciphertext[i:i+8])
-
-		// Decrypt block
-		decrypted := c.Decrypt(current)
-
-		// XOR with previous ciphertext block (or IV for first block)
-		for j := 0; j < 8; j++ {
-			plaintext[i+j] = decrypted[j] ^ prev[j]
-		}
-
-		// Update previous block
-		copy(prev, current)
-	}
-
-	// Remove PKCS7 padding
-	padLen := int(plaintext[len(plaintext)-1])
-	if padLen > 8 || padLen < 1 {
-		panic("rc5: invalid padding")
-	}
-	return plaintext[:len(plaintext)-padLen]
+// rotateLeft rotates the low w bits of x left by y mod w
+func rotateLeft(x, y uint64, w uint) uint64 {
+	n := uint(y % uint64(w))
+	x &= wordMask(w)
+	return ((x << n) | (x >> (w - n))) & wordMask(w)
 }
 
-// EncryptWithMode encrypts data using the specified mode and padding
-func (c *RC5) EncryptWithMode(data []byte, iv []byte, mode string, padding PaddingType) []byte {
-	// Get the mode implementation
-	modeImpl, err := GetMode(c, mode)
-	if err != nil {
-		return nil
-	}
-
-	// Add padding
-	blockSize := len(iv)
-	paddedData := Pad(data, blockSize, padding)
-
-	// Encrypt using the selected mode
-	return modeImpl.Encrypt(paddedData, iv)
+// rotateRight rotates the low w bits of x right by y mod w
+func rotateRight(x, y uint64, w uint) uint64 {
+	n := uint(y % uint64(w))
+	x &= wordMask(w)
+	return ((x >> n) | (x << (w - n))) & wordMask(w)
 }
 
-// DecryptWithMode decrypts data using the specified mode and padding
-func (c *RC5) DecryptWithMode(ciphertext []byte, iv []byte, mode string, padding PaddingType) []byte {
-	// Get the mode implementation
-	modeImpl, err := GetMode(c, mode)
-	if err != nil {
-		return nil
+// bytesToWord reads a little-endian word of len(b) bytes
+func bytesToWord(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
 	}
-
-	// Decrypt using the selected mode
-	decrypted := modeImpl.Decrypt(ciphertext, iv)
-
-	// Remove padding
-	return Unpad(decrypted, padding)
-}
-
-// Helper functions
-
-func rotateLeft(x, y uint32) uint32 {
-	return (x << (y & 31)) | (x >> (32 - (y & 31)))
-}
-
-func rotateRight(x, y uint32) uint32 {
-	return (x >> (y & 31)) | (x << (32 - (y & 31)))
+	return v
 }
 
-func bytesToUint32(b []byte) uint32 {
-	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
-}
-
-func uint32ToBytes(v uint32, b []byte) {
-	b[0] = byte(v)
-	b[1] = byte(v >> 8)
-	b[2] = byte(v >> 16)
-	b[3] = byte(v >> 24)
+// wordToBytes writes v as a little-endian word of len(b) bytes
+func wordToBytes(v uint64, b []byte) {
+	for i := range b {
+		b[i] = byte(v)
+		v >>= 8
+	}
 }
 
-func bytesToWords(key []byte) []uint32 {
-	pad := (8 - (len(key) % 8)) % 8
-	padded := append(key, make([]byte, pad)...)
+// bytesToWords splits the key into little-endian words of u bytes,
+// zero-padding the last one. An empty key yields a single zero word.
+func bytesToWords(key []byte, u int) []uint64 {
+	c := maxInt(1, (len(key)+u-1)/u)
+	padded := make([]byte, c*u)
+	copy(padded, key)
 
-	words := make([]uint32, len(padded)/4)
+	words := make([]uint64, c)
 	for i := range words {
-		words[i] = bytesToUint32(padded[i*4 : (i+1)*4])
+		words[i] = bytesToWord(padded[i*u : (i+1)*u])
 	}
 	return words
 }
@@ -0,0 +1,59 @@
+package crypto_test
+
+import (
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+	"CryptographyCW/pkg/crypto/kat"
+)
+
+func TestRC5KnownAnswers(t *testing.T) {
+	checkVectors(t, "rc5.txt")
+}
+
+// TestRC5WordSizes makes sure every supported word size has a vector
+func TestRC5WordSizes(t *testing.T) {
+	vectors, err := kat.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockSizes := make(map[int]bool)
+	for _, v := range vectors {
+		if v.File == "rc5.txt" {
+			blockSizes[len(v.Plaintext)] = true
+		}
+	}
+	for _, w := range []int{16, 32, 64} {
+		if !blockSizes[2*w/8] {
+			t.Errorf("no RC5-%d vector", w)
+		}
+	}
+}
+
+func TestNewRC5Parameters(t *testing.T) {
+	tests := []struct {
+		wordSize, rounds, keyLen int
+		ok                       bool
+	}{
+		{16, 16, 8, true},
+		{32, 0, 0, true},
+		{64, 255, 255, true},
+		{8, 12, 16, false},
+		{128, 12, 16, false},
+		{32, -1, 16, false},
+		{32, 256, 16, false},
+		{32, 12, 256, false},
+	}
+
+	for _, tt := range tests {
+		c, err := crypto.NewRC5(tt.wordSize, tt.rounds, make([]byte, tt.keyLen))
+		if (err == nil) != tt.ok {
+			t.Errorf("NewRC5(%d, %d, %d bytes): err = %v", tt.wordSize, tt.rounds, tt.keyLen, err)
+			continue
+		}
+		if err == nil && c.BlockSize() != 2*tt.wordSize/8 {
+			t.Errorf("RC5-%d: BlockSize() = %d", tt.wordSize, c.BlockSize())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package crypto
+
+import "errors"
+
+// Constants for RC6
+const (
+	// RC6BlockSize is the RC6 block size in bytes, four 32-bit words
+	RC6BlockSize = 16
+	// DefaultRC6Rounds is the number of rounds used when an algorithm name does not specify one
+	DefaultRC6Rounds = 20
+
+	rc6WordSize = 32
+	rc6LogW     = 5 // log2 of the word size, the fixed rotation in f
+)
+
+// RC6 represents an RC6-32/r/b cipher instance
+type RC6 struct {
+	blockModes
+	rounds int
+	S      []uint64
+}
+
+// NewRC6 creates a new RC6-32/r/b cipher instance with r rounds (0-255)
+// and a key of b bytes (0-255)
+func NewRC6(rounds int, key []byte) (*RC6, error) {
+	if rounds < 0 || rounds > 255 {
+		return nil, errors.New("rc6: number of rounds must be between 0 and 255")
+	}
+	if len(key) > 255 {
+		return nil, errors.New("rc6: key must be at most 255 bytes")
+	}
+
+	c := &RC6{
+		rounds: rounds,
+		S:      make([]uint64, 2*rounds+4),
+	}
+
+	c.blockModes = blockModes{c}
+	expandRC5Key(c.S, key, rc6WordSize)
+	return c, nil
+}
+
+func init() {
+	Register(Algorithm{
+		Name:      "RC6",
+		BlockSize: RC6BlockSize,
+		KeySizes:  KeySizes{Min: 0, Max: 255, Step: 1},
+		KeySize:   32,
+		Parameter: "rounds",
+		New: func(key []byte, param string) (Cipher, error) {
+			rounds, err := parseRounds(param, DefaultRC6Rounds)
+			if err != nil {
+				return nil, err
+			}
+			return NewRC6(rounds, key)
+		},
+	})
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (c *RC6) BlockSize() int {
+	return RC6BlockSize
+}
+
+// f computes x(2x+1) <<< lg w, the quadratic function mixed into each round
+func (c *RC6) f(x uint64) uint64 {
+	return rotateLeft(x*(2*x+1), rc6LogW, rc6WordSize)
+}
+
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (c *RC6) encryptBlock(dst, src []byte) {
+	w, mask := uint(rc6WordSize), wordMask(rc6WordSize)
+	A := bytesToWord(src[0:4])
+	B := bytesToWord(src[4:8])
+	C := bytesToWord(src[8:12])
+	D := bytesToWord(src[12:16])
+
+	B = (B + c.S[0]) & mask
+	D = (D + c.S[1]) & mask
+
+	for i := 1; i <= c.rounds; i++ {
+		t := c.f(B)
+		u := c.f(D)
+		A = (rotateLeft(A^t, u, w) + c.S[2*i]) & mask
+		C = (rotateLeft(C^u, t, w) + c.S[2*i+1]) & mask
+		A, B, C, D = B, C, D, A
+	}
+
+	A = (A + c.S[2*c.rounds+2]) & mask
+	C = (C + c.S[2*c.rounds+3]) & mask
+
+	wordToBytes(A, dst[0:4])
+	wordToBytes(B, dst[4:8])
+	wordToBytes(C, dst[8:12])
+	wordToBytes(D, dst[12:16])
+}
+
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (c *RC6) decryptBlock(dst, src []byte) {
+	w, mask := uint(rc6WordSize), wordMask(rc6WordSize)
+	A := bytesToWord(src[0:4])
+	B := bytesToWord(src[4:8])
+	C := bytesToWord(src[8:12])
+	D := bytesToWord(src[12:16])
+
+	C = (C - c.S[2*c.rounds+3]) & mask
+	A = (A - c.S[2*c.rounds+2]) & mask
+
+	for i := c.rounds; i >= 1; i-- {
+		A, B, C, D = D, A, B, C
+		u := c.f(D)
+		t := c.f(B)
+		C = rotateRight(C-c.S[2*i+1], t, w) ^ u
+		A = rotateRight(A-c.S[2*i], u, w) ^ t
+	}
+
+	D = (D - c.S[1]) & mask
+	B = (B - c.S[0]) & mask
+
+	wordToBytes(A, dst[0:4])
+	wordToBytes(B, dst[4:8])
+	wordToBytes(C, dst[8:12])
+	wordToBytes(D, dst[12:16])
+}
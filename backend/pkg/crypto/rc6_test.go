@@ -0,0 +1,47 @@
+package crypto_test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+func TestRC6KnownAnswers(t *testing.T) {
+	checkVectors(t, "rc6.txt")
+}
+
+// TestRC6Rounds checks that the registry's "/r" suffix selects the rounds
+func TestRC6Rounds(t *testing.T) {
+	key, pt := testKey(16), testMessage(crypto.RC6BlockSize)
+	for _, rounds := range []int{0, 12, crypto.DefaultRC6Rounds} {
+		c, err := crypto.NewRC6(rounds, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := c.Encrypt(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		named, err := crypto.NewCipher("RC6/"+strconv.Itoa(rounds), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := named.Encrypt(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("RC6/%d: got %X, want %X", rounds, got, want)
+		}
+	}
+
+	if _, err := crypto.NewRC6(256, key); err == nil {
+		t.Error("256 rounds accepted")
+	}
+	if _, err := crypto.NewRC6(20, make([]byte, 256)); err == nil {
+		t.Error("256-byte key accepted")
+	}
+}
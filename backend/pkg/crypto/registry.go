@@ -0,0 +1,122 @@
+package crypto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// KeySizes describes the key lengths an algorithm accepts: every length
+// from Min to Max bytes in steps of Step
+type KeySizes struct {
+	Min  int `json:"min"`
+	Max  int `json:"max"`
+	Step int `json:"step"`
+}
+
+// Valid reports whether a key of n bytes is accepted
+func (k KeySizes) Valid(n int) bool {
+	return n >= k.Min && n <= k.Max && (k.Step <= 1 || (n-k.Min)%k.Step == 0)
+}
+
+// Algorithm describes a cipher that NewCipher can create
+type Algorithm struct {
+	// Name is the algorithm name used in room settings, e.g. "RC5-64"
+	Name string `json:"name"`
+	// BlockSize is the block size in bytes
+	BlockSize int `json:"block_size"`
+	// KeySizes are the accepted key lengths
+	KeySizes KeySizes `json:"key_sizes"`
+	// KeySize is the length of keys derived for the algorithm by
+	// DeriveSessionKey
+	KeySize int `json:"key_size"`
+	// Parameter names the optional value after a "/" in the algorithm name,
+	// e.g. "rounds" for "RC6/12", or is empty if there is none
+	Parameter string `json:"parameter,omitempty"`
+	// New creates a cipher with the given key; param is the text after the
+	// "/" or empty
+	New func(key []byte, param string) (Cipher, error) `json:"-"`
+}
+
+var registry struct {
+	sync.RWMutex
+	byName map[string]*Algorithm
+	order  []*Algorithm
+}
+
+// Register makes an algorithm available to NewCipher. It panics if the
+// name is already taken or the description is incomplete.
+func Register(a Algorithm) {
+	if a.Name == "" || strings.Contains(a.Name, "/") || a.New == nil || a.BlockSize <= 0 {
+		panic("crypto: invalid algorithm registration " + strconv.Quote(a.Name))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.byName == nil {
+		registry.byName = make(map[string]*Algorithm)
+	}
+	if _, dup := registry.byName[a.Name]; dup {
+		panic(fmt.Sprintf("crypto: algorithm %q registered twice", a.Name))
+	}
+	registry.byName[a.Name] = &a
+	registry.order = append(registry.order, &a)
+}
+
+// LookupAlgorithm returns the registered algorithm for a name, which may
+// carry a "/" parameter such as "RC5-64/16"
+func LookupAlgorithm(name string) (Algorithm, bool) {
+	base, _, _ := strings.Cut(name, "/")
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	a, ok := registry.byName[base]
+	if !ok {
+		return Algorithm{}, false
+	}
+	return *a, true
+}
+
+// Algorithms returns every registered algorithm in registration order
+func Algorithms() []Algorithm {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	list := make([]Algorithm, len(registry.order))
+	for i, a := range registry.order {
+		list[i] = *a
+	}
+	return list
+}
+
+// Catalog lists what a room can be created with: the registered
+// algorithms and the supported modes and paddings
+type Catalog struct {
+	Algorithms []Algorithm   `json:"algorithms"`
+	Modes      []string      `json:"modes"`
+	Paddings   []PaddingType `json:"paddings"`
+}
+
+// Supported returns the Catalog of everything currently registered
+func Supported() Catalog {
+	return Catalog{
+		Algorithms: Algorithms(),
+		Modes:      Modes,
+		Paddings:   Paddings,
+	}
+}
+
+// parseRounds reads a round count parameter, using def when it is empty
+func parseRounds(param string, def int) (int, error) {
+	if param == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of rounds %q", param)
+	}
+	return n, nil
+}
@@ -0,0 +1,309 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"CryptographyCW/pkg/crypto/gf256"
+)
+
+// Rijndael represents a Rijndael cipher instance with a 128, 192 or 256-bit
+// block and key. AES is the 128-bit block size with the AES modulus.
+type Rijndael struct {
+	blockModes
+	nb, nr   int          // block size in words and number of rounds
+	shifts   [4]int       // ShiftRows offset of each row
+	roundKey []uint32     // expanded key, nb words per round key
+	sbox     [256]byte    // SubBytes table for the chosen modulus
+	invSbox  [256]byte    // InvSubBytes table
+	mix      [4][256]byte // products with each MixColumns coefficient
+	invMix   [4][256]byte // products with each InvMixColumns coefficient
+}
+
+// rijndaelMixPoly holds the coefficients of 03x^3 + 01x^2 + 01x + 02, lowest first
+var rijndaelMixPoly = [4]byte{0x02, 0x01, 0x01, 0x03}
+
+// NewAES creates an AES cipher instance with a 16, 24 or 32-byte key
+func NewAES(key []byte) (*Rijndael, error) {
+	return NewRijndael(16, key, gf256.AES)
+}
+
+// NewRijndael creates a Rijndael cipher instance with a block size of 16, 24
+// or 32 bytes, a key of 16, 24 or 32 bytes and GF(2^8) arithmetic modulo
+// poly, which must be irreducible of degree 8
+func NewRijndael(blockSize int, key []byte, poly uint16) (*Rijndael, error) {
+	if blockSize != 16 && blockSize != 24 && blockSize != 32 {
+		return nil, errors.New("rijndael: block size must be 16, 24, or 32 bytes")
+	}
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("rijndael: key must be 16, 24, or 32 bytes")
+	}
+	if !gf256.IsIrreducible(poly) {
+		return nil, gf256.ErrNotIrreducible
+	}
+
+	nb, nk := blockSize/4, len(key)/4
+	r := &Rijndael{
+		nb:     nb,
+		nr:     maxInt(nb, nk) + 6,
+		shifts: [4]int{0, 1, 2, 3},
+	}
+	if nb == 8 {
+		r.shifts = [4]int{0, 1, 3, 4}
+	}
+
+	r.blockModes = blockModes{r}
+	r.buildTables(poly)
+	r.expandKey(key, poly)
+	return r, nil
+}
+
+func init() {
+	Register(Algorithm{
+		Name:      "AES",
+		BlockSize: 16,
+		KeySizes:  KeySizes{Min: 16, Max: 32, Step: 8},
+		KeySize:   32,
+		New: func(key []byte, _ string) (Cipher, error) {
+			return NewAES(key)
+		},
+	})
+
+	// "Rijndael" alone has a 128-bit block; a "/hex" suffix picks the
+	// GF(2^8) modulus, e.g. "Rijndael-192/11D"
+	for _, v := range []struct {
+		name      string
+		blockSize int
+	}{{"Rijndael", 16}, {"Rijndael-192", 24}, {"Rijndael-256", 32}} {
+		blockSize := v.blockSize
+		Register(Algorithm{
+			Name:      v.name,
+			BlockSize: blockSize,
+			KeySizes:  KeySizes{Min: 16, Max: 32, Step: 8},
+			KeySize:   32,
+			Parameter: "modulus",
+			New: func(key []byte, param string) (Cipher, error) {
+				poly := gf256.AES
+				if param != "" {
+					var err error
+					if poly, err = ParseModulus(param); err != nil {
+						return nil, err
+					}
+				}
+				return NewRijndael(blockSize, key, poly)
+			},
+		})
+	}
+}
+
+// ParseModulus reads a GF(2^8) modulus given in hex with an optional "0x"
+// prefix, e.g. "11D" or "0x11D", and checks that it is irreducible
+func ParseModulus(s string) (uint16, error) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid modulus %q", s)
+	}
+	if !gf256.IsIrreducible(uint16(n)) {
+		return 0, gf256.ErrNotIrreducible
+	}
+	return uint16(n), nil
+}
+
+// buildTables computes the S-boxes and multiplication tables for the modulus
+func (r *Rijndael) buildTables(poly uint16) {
+	for i := 0; i < 256; i++ {
+		b := inverse(byte(i), poly)
+
+		// Affine transformation over GF(2)
+		s := b ^ rotl8(b, 1) ^ rotl8(b, 2) ^ rotl8(b, 3) ^ rotl8(b, 4) ^ 0x63
+		r.sbox[i] = s
+		r.invSbox[s] = byte(i)
+	}
+
+	// The inverse MixColumns polynomial depends on the modulus; for the AES
+	// modulus it is 0Bx^3 + 0Dx^2 + 09x + 0E
+	inv := invertMixPoly(rijndaelMixPoly, poly)
+	for j := 0; j < 4; j++ {
+		for x := 0; x < 256; x++ {
+			r.mix[j][x] = gf256.Mul(byte(x), rijndaelMixPoly[j], poly)
+			r.invMix[j][x] = gf256.Mul(byte(x), inv[j], poly)
+		}
+	}
+}
+
+// invertMixPoly finds d(x) with c(x) * d(x) = 1 modulo x^4 + 1 by inverting
+// the circulant matrix of c(x) with Gauss-Jordan elimination
+func invertMixPoly(c [4]byte, poly uint16) [4]byte {
+	// Row i of the augmented matrix multiplies a column into output byte i
+	var m [4][8]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			m[i][j] = c[(i-j)&3]
+		}
+		m[i][4+i] = 1
+	}
+
+	// c(1) = 1, so c(x) is coprime to x^4 + 1 = (x + 1)^4 and a pivot always exists
+	for col := 0; col < 4; col++ {
+		pivot := col
+		for m[pivot][col] == 0 {
+			pivot++
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		inv := inverse(m[col][col], poly)
+		for j := range m[col] {
+			m[col][j] = gf256.Mul(m[col][j], inv, poly)
+		}
+		for i := 0; i < 4; i++ {
+			if i == col || m[i][col] == 0 {
+				continue
+			}
+			f := m[i][col]
+			for j := range m[i] {
+				m[i][j] ^= gf256.Mul(f, m[col][j], poly)
+			}
+		}
+	}
+
+	// The inverse is circulant too, its first column holds d(x)
+	var d [4]byte
+	for i := 0; i < 4; i++ {
+		d[i] = m[i][4]
+	}
+	return d
+}
+
+// inverse returns a^-1 modulo poly, taking the inverse of zero to be zero.
+// Unlike gf256.Inverse it does not test poly, which NewRijndael checks once.
+func inverse(a byte, poly uint16) byte {
+	// a^254 = a^-1 in a field of 256 elements, and 0^254 = 0
+	return gf256.Pow(a, 254, poly)
+}
+
+// rotl8 rotates a byte left by n bits
+func rotl8(b byte, n uint) byte {
+	return b<<n | b>>(8-n)
+}
+
+// expandKey computes nb*(nr+1) words of round keys
+func (r *Rijndael) expandKey(key []byte, poly uint16) {
+	nk := len(key) / 4
+	w := make([]uint32, r.nb*(r.nr+1))
+	for i := 0; i < nk; i++ {
+		w[i] = uint32(key[4*i])<<24 | uint32(key[4*i+1])<<16 | uint32(key[4*i+2])<<8 | uint32(key[4*i+3])
+	}
+
+	rcon := byte(1)
+	for i := nk; i < len(w); i++ {
+		t := w[i-1]
+		switch {
+		case i%nk == 0:
+			t = r.subWord(t<<8|t>>24) ^ uint32(rcon)<<24
+			rcon = gf256.Mul(rcon, 2, poly)
+		case nk > 6 && i%nk == 4:
+			t = r.subWord(t)
+		}
+		w[i] = w[i-nk] ^ t
+	}
+	r.roundKey = w
+}
+
+// subWord applies the S-box to each byte of a word
+func (r *Rijndael) subWord(w uint32) uint32 {
+	return uint32(r.sbox[w>>24])<<24 | uint32(r.sbox[byte(w>>16)])<<16 |
+		uint32(r.sbox[byte(w>>8)])<<8 | uint32(r.sbox[byte(w)])
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (r *Rijndael) BlockSize() int {
+	return 4 * r.nb
+}
+
+// addRoundKey XORs round key n into the state, which holds the block
+// column by column as in the specification
+func (r *Rijndael) addRoundKey(state []byte, n int) {
+	for c := 0; c < r.nb; c++ {
+		k := r.roundKey[n*r.nb+c]
+		state[4*c] ^= byte(k >> 24)
+		state[4*c+1] ^= byte(k >> 16)
+		state[4*c+2] ^= byte(k >> 8)
+		state[4*c+3] ^= byte(k)
+	}
+}
+
+// shiftRows rotates row i of the state left by shifts[i] columns, or right
+// when inverse is set
+func (r *Rijndael) shiftRows(state []byte, inverse bool) {
+	var row [8]byte
+	for i := 1; i < 4; i++ {
+		s := r.shifts[i]
+		if inverse {
+			s = r.nb - s
+		}
+		for c := 0; c < r.nb; c++ {
+			row[c] = state[4*((c+s)%r.nb)+i]
+		}
+		for c := 0; c < r.nb; c++ {
+			state[4*c+i] = row[c]
+		}
+	}
+}
+
+// mixColumns multiplies each column by a polynomial modulo x^4 + 1, given
+// as tables of products with each coefficient: mix for MixColumns, invMix
+// for InvMixColumns
+func (r *Rijndael) mixColumns(state []byte, t *[4][256]byte) {
+	for c := 0; c < r.nb; c++ {
+		a := state[4*c : 4*c+4]
+		a0, a1, a2, a3 := a[0], a[1], a[2], a[3]
+		a[0] = t[0][a0] ^ t[3][a1] ^ t[2][a2] ^ t[1][a3]
+		a[1] = t[1][a0] ^ t[0][a1] ^ t[3][a2] ^ t[2][a3]
+		a[2] = t[2][a0] ^ t[1][a1] ^ t[0][a2] ^ t[3][a3]
+		a[3] = t[3][a0] ^ t[2][a1] ^ t[1][a2] ^ t[0][a3]
+	}
+}
+
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (r *Rijndael) encryptBlock(dst, src []byte) {
+	var buf [32]byte
+	state := buf[:r.BlockSize()]
+	copy(state, src)
+
+	r.addRoundKey(state, 0)
+	for round := 1; round <= r.nr; round++ {
+		for i, b := range state {
+			state[i] = r.sbox[b]
+		}
+		r.shiftRows(state, false)
+		if round < r.nr {
+			r.mixColumns(state, &r.mix)
+		}
+		r.addRoundKey(state, round)
+	}
+
+	copy(dst, state)
+}
+
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (r *Rijndael) decryptBlock(dst, src []byte) {
+	var buf [32]byte
+	state := buf[:r.BlockSize()]
+	copy(state, src)
+
+	r.addRoundKey(state, r.nr)
+	for round := r.nr - 1; round >= 0; round-- {
+		r.shiftRows(state, true)
+		for i, b := range state {
+			state[i] = r.invSbox[b]
+		}
+		r.addRoundKey(state, round)
+		if round > 0 {
+			r.mixColumns(state, &r.invMix)
+		}
+	}
+
+	copy(dst, state)
+}
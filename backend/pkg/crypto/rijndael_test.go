@@ -0,0 +1,99 @@
+package crypto_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+	"CryptographyCW/pkg/crypto/gf256"
+)
+
+func TestAESKnownAnswers(t *testing.T) {
+	checkVectors(t, "aes.txt")
+}
+
+func TestRijndaelKnownAnswers(t *testing.T) {
+	checkVectors(t, "rijndael.txt")
+}
+
+func TestAESMatchesStdlib(t *testing.T) {
+	for _, n := range []int{16, 24, 32} {
+		key := testKey(n)
+		c, err := crypto.NewAES(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pt := testMessage(aes.BlockSize)
+		want := make([]byte, aes.BlockSize)
+		ref.Encrypt(want, pt)
+		got, err := c.Encrypt(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("AES-%d: got %X, want %X", 8*n, got, want)
+		}
+	}
+}
+
+// TestRijndaelModulus checks that another irreducible modulus gives a
+// different but working cipher, and that a reducible one is rejected
+func TestRijndaelModulus(t *testing.T) {
+	key := testKey(16)
+	for _, bs := range []int{16, 24, 32} {
+		std, err := crypto.NewRijndael(bs, key, gf256.AES)
+		if err != nil {
+			t.Fatal(err)
+		}
+		alt, err := crypto.NewRijndael(bs, key, 0x11D)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pt := testMessage(bs)
+		a, err := std.Encrypt(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := alt.Encrypt(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(a, b) {
+			t.Errorf("%d-byte block: modulus 0x11D gave the AES ciphertext", bs)
+		}
+		if got, err := alt.Decrypt(b); err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("%d-byte block: modulus 0x11D round trip got %X, %v", bs, got, err)
+		}
+	}
+
+	if _, err := crypto.NewRijndael(16, key, 0x100); err == nil {
+		t.Error("reducible modulus 0x100 accepted")
+	}
+}
+
+func TestParseModulus(t *testing.T) {
+	for _, s := range []string{"11D", "0x11D", "11d", "11B"} {
+		if _, err := crypto.ParseModulus(s); err != nil {
+			t.Errorf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "0x", "XYZ", "0X11D", "10000", "100"} {
+		if _, err := crypto.ParseModulus(s); err == nil {
+			t.Errorf("%q accepted", s)
+		}
+	}
+
+	// The registry takes the same spellings as the room form
+	for _, name := range []string{"Rijndael-192/11D", "Rijndael-192/0x11D"} {
+		if _, err := crypto.NewCipher(name, testKey(16)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+)
+
+// mgf1 xors out with the MGF1-SHA256 mask generated from seed
+func mgf1(out, seed []byte) {
+	var counter [4]byte
+	for done := 0; done < len(out); {
+		h := sha256.New()
+		h.Write(seed)
+		h.Write(counter[:])
+		digest := h.Sum(nil)
+
+		for i := 0; i < len(digest) && done < len(out); i++ {
+			out[done] ^= digest[i]
+			done++
+		}
+		binary.BigEndian.PutUint32(counter[:], binary.BigEndian.Uint32(counter[:])+1)
+	}
+}
+
+// EncryptOAEP encrypts msg with RSAES-OAEP using SHA-256 and MGF1. The label
+// is bound to the ciphertext and must be given again to decrypt; it may be nil.
+// msg can be at most Size() - 66 bytes long.
+func EncryptOAEP(pub *PublicKey, msg, label []byte) ([]byte, error) {
+	k := pub.Size()
+	hLen := sha256.Size
+	if len(msg) > k-2*hLen-2 {
+		return nil, ErrMessageTooLong
+	}
+
+	// EM = 0x00 || maskedSeed || maskedDB, DB = lHash || PS || 0x01 || M
+	em := make([]byte, k)
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+
+	lHash := sha256.Sum256(label)
+	copy(db, lHash[:])
+	db[len(db)-len(msg)-1] = 0x01
+	copy(db[len(db)-len(msg):], msg)
+
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	mgf1(db, seed)
+	mgf1(seed, db)
+
+	c := encrypt(pub, new(big.Int).SetBytes(em))
+	return c.FillBytes(make([]byte, k)), nil
+}
+
+// DecryptOAEP decrypts a ciphertext produced by EncryptOAEP with the same label.
+// All padding failures return the same error.
+func DecryptOAEP(priv *PrivateKey, ciphertext, label []byte) ([]byte, error) {
+	k := priv.Size()
+	hLen := sha256.Size
+	if len(ciphertext) != k || k < 2*hLen+2 {
+		return nil, ErrDecryption
+	}
+
+	m, err := decrypt(priv, new(big.Int).SetBytes(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	em := m.FillBytes(make([]byte, k))
+
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+	mgf1(seed, db)
+	mgf1(db, seed)
+
+	// Check the padding without branching on secret data until the end
+	lHash := sha256.Sum256(label)
+	good := subtle.ConstantTimeByteEq(em[0], 0)
+	good &= subtle.ConstantTimeCompare(db[:hLen], lHash[:])
+
+	// Find the 0x01 separator; everything before it must be zero
+	lookingForIndex, index, invalid := 1, 0, 0
+	rest := db[hLen:]
+	for i, b := range rest {
+		isZero := subtle.ConstantTimeByteEq(b, 0)
+		isOne := subtle.ConstantTimeByteEq(b, 1)
+		index = subtle.ConstantTimeSelect(lookingForIndex&isOne, i, index)
+		lookingForIndex = subtle.ConstantTimeSelect(isOne, 0, lookingForIndex)
+		invalid = subtle.ConstantTimeSelect(lookingForIndex&^isZero, 1, invalid)
+	}
+
+	if good&^invalid&^lookingForIndex != 1 {
+		return nil, ErrDecryption
+	}
+	return append([]byte(nil), rest[index+1:]...), nil
+}
@@ -0,0 +1,73 @@
+package rsa
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"math/big"
+)
+
+// sha256Prefix is the DER encoding of the DigestInfo header for SHA-256
+var sha256Prefix = []byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20}
+
+// encodeSignature builds the EMSA-PKCS1-v1_5 encoding of a SHA-256 digest:
+// 0x00 || 0x01 || 0xFF... || 0x00 || DigestInfo
+func encodeSignature(k int, digest []byte) ([]byte, error) {
+	tLen := len(sha256Prefix) + len(digest)
+	if k < tLen+11 {
+		return nil, ErrMessageTooLong
+	}
+
+	em := make([]byte, k)
+	em[1] = 0x01
+	for i := 2; i < k-tLen-1; i++ {
+		em[i] = 0xFF
+	}
+	copy(em[k-tLen:], sha256Prefix)
+	copy(em[k-len(digest):], digest)
+	return em, nil
+}
+
+// Sign signs msg with RSASSA-PKCS1-v1_5 and SHA-256
+func Sign(priv *PrivateKey, msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+	em, err := encodeSignature(priv.Size(), digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := decrypt(priv, new(big.Int).SetBytes(em))
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the result to avoid leaking a factor through a faulty CRT computation
+	if encrypt(&priv.PublicKey, s).Cmp(new(big.Int).SetBytes(em)) != 0 {
+		return nil, ErrVerification
+	}
+	return s.FillBytes(make([]byte, priv.Size())), nil
+}
+
+// Verify checks a signature produced by Sign
+func Verify(pub *PublicKey, msg, sig []byte) error {
+	k := pub.Size()
+	if len(sig) != k {
+		return ErrVerification
+	}
+
+	s := new(big.Int).SetBytes(sig)
+	if s.Cmp(pub.N) >= 0 {
+		return ErrVerification
+	}
+
+	digest := sha256.Sum256(msg)
+	want, err := encodeSignature(k, digest[:])
+	if err != nil {
+		return err
+	}
+
+	got := encrypt(pub, s).FillBytes(make([]byte, k))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrVerification
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package rsa implements RSA key generation, encryption and signatures on
+// top of math/big.
+//
+// Encryption uses OAEP padding and signatures use the PKCS #1 v1.5 encoding,
+// both with SHA-256. Key generation takes a pluggable primality test and
+// rejects keys whose private exponent is small enough for Wiener's attack.
+package rsa
+
+import (
+	"CryptographyCW/pkg/crypto/primes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// E is the public exponent used for generated keys
+const E = 65537
+
+var (
+	ErrKeySize         = errors.New("rsa: key must be at least 512 bits and a multiple of 16")
+	ErrMessageTooLong  = errors.New("rsa: message too long for the key size")
+	ErrDecryption      = errors.New("rsa: decryption error")
+	ErrVerification    = errors.New("rsa: verification error")
+	ErrInvalidKey      = errors.New("rsa: invalid key")
+	ErrInvalidArgument = errors.New("rsa: invalid argument")
+)
+
+// PrimalityTest decides whether n is prime, see primes.PrimalityTest
+type PrimalityTest = primes.PrimalityTest
+
+// PublicKey is an RSA public key
+type PublicKey struct {
+	N *big.Int // modulus
+	E int      // public exponent
+}
+
+// Size returns the modulus length in bytes
+func (pub *PublicKey) Size() int {
+	return (pub.N.BitLen() + 7) / 8
+}
+
+// PrivateKey is an RSA private key with the CRT values used to speed up
+// decryption and signing
+type PrivateKey struct {
+	PublicKey
+	D    *big.Int // private exponent
+	P, Q *big.Int // prime factors of N
+	Dp   *big.Int // D mod (P-1)
+	Dq   *big.Int // D mod (Q-1)
+	Qinv *big.Int // Q^-1 mod P
+}
+
+// GenerateKey creates a key with a modulus of the given number of bits.
+// Primes are accepted by test with at least minProbability; a nil test
+// means primes.MillerRabin and a zero probability means
+// primes.DefaultProbability.
+//
+// The factors are kept far apart so N cannot be factored by Fermat's
+// method, and keys whose private exponent is shorter than half the modulus
+// are regenerated. That is well above the N^(1/4)/3 bound below which
+// Wiener's continued fraction attack recovers d from (e, N).
+func GenerateKey(bits int, test PrimalityTest, minProbability float64) (*PrivateKey, error) {
+	if bits < 512 || bits%16 != 0 {
+		return nil, ErrKeySize
+	}
+	if test == nil {
+		test = primes.MillerRabin{}
+	}
+	if minProbability == 0 {
+		minProbability = primes.DefaultProbability
+	}
+	if minProbability < 0.5 || minProbability >= 1 {
+		return nil, ErrInvalidArgument
+	}
+
+	e := big.NewInt(E)
+	one := big.NewInt(1)
+	// |p - q| must exceed 2^(bits/2 - 100)
+	minDistance := new(big.Int).Lsh(one, uint(bits/2-100))
+
+	for {
+		p, err := generatePrime(bits/2, test, minProbability)
+		if err != nil {
+			return nil, err
+		}
+		q, err := generatePrime(bits/2, test, minProbability)
+		if err != nil {
+			return nil, err
+		}
+
+		distance := new(big.Int).Sub(p, q)
+		if distance.Abs(distance).Cmp(minDistance) <= 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+
+		pMinus1 := new(big.Int).Sub(p, one)
+		qMinus1 := new(big.Int).Sub(q, one)
+		phi := new(big.Int).Mul(pMinus1, qMinus1)
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+
+		// Defence against Wiener's attack and its lattice extensions
+		if d.BitLen() <= bits/2 {
+			continue
+		}
+
+		if p.Cmp(q) < 0 {
+			p, q = q, p
+			pMinus1, qMinus1 = qMinus1, pMinus1
+		}
+
+		return &PrivateKey{
+			PublicKey: PublicKey{N: n, E: E},
+			D:         d,
+			P:         p,
+			Q:         q,
+			Dp:        new(big.Int).Mod(d, pMinus1),
+			Dq:        new(big.Int).Mod(d, qMinus1),
+			Qinv:      new(big.Int).ModInverse(q, p),
+		}, nil
+	}
+}
+
+// generatePrime returns a prime of exactly the given number of bits with
+// the top two bits set, so the product of two such primes has twice as many
+// bits, and p-1 coprime to E
+func generatePrime(bits int, test PrimalityTest, minProbability float64) (*big.Int, error) {
+	buf := make([]byte, (bits+7)/8)
+	e := big.NewInt(E)
+	r := new(big.Int)
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+
+		// Clear the excess bits, then set the top two and the lowest
+		excess := uint(len(buf)*8 - bits)
+		buf[0] &= 0xFF >> excess
+		if excess <= 6 {
+			buf[0] |= 0xC0 >> excess
+		} else {
+			buf[0] |= 0x01
+			buf[1] |= 0x80
+		}
+		buf[len(buf)-1] |= 1
+
+		p := new(big.Int).SetBytes(buf)
+		// E is prime, so gcd(E, p-1) = 1 unless p = 1 mod E
+		if r.Mod(p, e).Int64() == 1 {
+			continue
+		}
+		ok, err := test.IsProbablyPrime(p, minProbability)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return p, nil
+		}
+	}
+}
+
+// Validate checks that the key is consistent
+func (priv *PrivateKey) Validate() error {
+	one := big.NewInt(1)
+	if priv.N == nil || priv.D == nil || priv.P == nil || priv.Q == nil || priv.E < 3 {
+		return ErrInvalidKey
+	}
+	if new(big.Int).Mul(priv.P, priv.Q).Cmp(priv.N) != 0 {
+		return ErrInvalidKey
+	}
+
+	// e*d = 1 mod (p-1) and mod (q-1)
+	ed := new(big.Int).Mul(big.NewInt(int64(priv.E)), priv.D)
+	for _, prime := range []*big.Int{priv.P, priv.Q} {
+		m := new(big.Int).Sub(prime, one)
+		if new(big.Int).Mod(ed, m).Cmp(one) != 0 {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// encrypt computes m^e mod N
+func encrypt(pub *PublicKey, m *big.Int) *big.Int {
+	return new(big.Int).Exp(m, big.NewInt(int64(pub.E)), pub.N)
+}
+
+// decrypt computes c^d mod N using the CRT. The input is blinded with a
+// random r^e so the timing of the exponentiations does not depend on c.
+func decrypt(priv *PrivateKey, c *big.Int) (*big.Int, error) {
+	if c.Sign() < 0 || c.Cmp(priv.N) >= 0 {
+		return nil, ErrDecryption
+	}
+
+	var r, rInv *big.Int
+	for {
+		var err error
+		r, err = rand.Int(rand.Reader, priv.N)
+		if err != nil {
+			return nil, err
+		}
+		if r.Sign() == 0 {
+			continue
+		}
+		if rInv = new(big.Int).ModInverse(r, priv.N); rInv != nil {
+			break
+		}
+	}
+	blinded := new(big.Int).Mul(c, encrypt(&priv.PublicKey, r))
+	blinded.Mod(blinded, priv.N)
+
+	// m1 = c^dP mod p, m2 = c^dQ mod q, h = qInv (m1 - m2) mod p, m = m2 + h q
+	m1 := new(big.Int).Exp(blinded, priv.Dp, priv.P)
+	m2 := new(big.Int).Exp(blinded, priv.Dq, priv.Q)
+	h := m1.Sub(m1, m2)
+	h.Mul(h, priv.Qinv)
+	h.Mod(h, priv.P)
+	m := h.Mul(h, priv.Q)
+	m.Add(m, m2)
+
+	m.Mul(m, rInv)
+	return m.Mod(m, priv.N), nil
+}
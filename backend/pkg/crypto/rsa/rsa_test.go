@@ -0,0 +1,201 @@
+package rsa_test
+
+import (
+	"bytes"
+	stdcrypto "crypto"
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"CryptographyCW/pkg/crypto/primes"
+	"CryptographyCW/pkg/crypto/rsa"
+)
+
+// stdKey converts a key for use with crypto/rsa
+func stdKey(t *testing.T, priv *rsa.PrivateKey) *stdrsa.PrivateKey {
+	t.Helper()
+	k := &stdrsa.PrivateKey{
+		PublicKey: stdrsa.PublicKey{N: priv.N, E: priv.E},
+		D:         priv.D,
+		Primes:    []*big.Int{priv.P, priv.Q},
+	}
+	if err := k.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	k.Precompute()
+	return k
+}
+
+func TestGenerateKey(t *testing.T) {
+	priv, err := rsa.GenerateKey(1024, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if priv.N.BitLen() != 1024 || priv.E != rsa.E {
+		t.Errorf("N has %d bits, e = %d", priv.N.BitLen(), priv.E)
+	}
+	for _, p := range []*big.Int{priv.P, priv.Q} {
+		if !p.ProbablyPrime(20) {
+			t.Errorf("factor %v is not prime", p)
+		}
+	}
+
+	// d must exceed the Wiener bound N^(1/4)/3
+	bound := new(big.Int).Sqrt(new(big.Int).Sqrt(priv.N))
+	bound.Quo(bound, big.NewInt(3))
+	if priv.D.Cmp(bound) <= 0 {
+		t.Errorf("d = %v is below N^(1/4)/3", priv.D)
+	}
+
+	// Any primality test can be plugged in
+	for _, test := range []primes.PrimalityTest{primes.Fermat{}, primes.SolovayStrassen{}} {
+		k, err := rsa.GenerateKey(512, test, 0.99)
+		if err != nil {
+			t.Fatalf("%T: %v", test, err)
+		}
+		if err := k.Validate(); err != nil {
+			t.Errorf("%T: %v", test, err)
+		}
+	}
+}
+
+func TestGenerateKeyErrors(t *testing.T) {
+	for _, bits := range []int{0, 256, 511, 520} {
+		if _, err := rsa.GenerateKey(bits, nil, 0); !errors.Is(err, rsa.ErrKeySize) {
+			t.Errorf("%d bits: err = %v", bits, err)
+		}
+	}
+	for _, p := range []float64{0.4, 1, 2} {
+		if _, err := rsa.GenerateKey(512, nil, p); !errors.Is(err, rsa.ErrInvalidArgument) {
+			t.Errorf("probability %v: err = %v", p, err)
+		}
+	}
+}
+
+func TestOAEP(t *testing.T) {
+	priv, err := rsa.GenerateKey(1024, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std := stdKey(t, priv)
+	label := []byte("room")
+	maxLen := priv.Size() - 2*sha256.Size - 2
+
+	for _, msg := range [][]byte{{}, []byte("hello"), bytes.Repeat([]byte{0xA5}, maxLen)} {
+		c, err := rsa.EncryptOAEP(&priv.PublicKey, msg, label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := rsa.DecryptOAEP(priv, c, label)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("%d bytes: round trip: %x, %v", len(msg), got, err)
+		}
+
+		// Both directions against crypto/rsa
+		got, err = stdrsa.DecryptOAEP(sha256.New(), nil, std, c, label)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("%d bytes: crypto/rsa cannot decrypt: %v", len(msg), err)
+		}
+		c, err = stdrsa.EncryptOAEP(sha256.New(), rand.Reader, &std.PublicKey, msg, label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = rsa.DecryptOAEP(priv, c, label)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("%d bytes: cannot decrypt crypto/rsa output: %v", len(msg), err)
+		}
+	}
+
+	if _, err := rsa.EncryptOAEP(&priv.PublicKey, make([]byte, maxLen+1), nil); !errors.Is(err, rsa.ErrMessageTooLong) {
+		t.Errorf("long message: err = %v", err)
+	}
+}
+
+func TestOAEPTampered(t *testing.T) {
+	priv, err := rsa.GenerateKey(1024, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := rsa.EncryptOAEP(&priv.PublicKey, []byte("hello"), []byte("room"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := bytes.Clone(c)
+	flipped[len(c)/2] ^= 0x01
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		label      []byte
+	}{
+		{"bit flip", flipped, []byte("room")},
+		{"wrong label", c, []byte("hall")},
+		{"no label", c, nil},
+		{"truncated", c[1:], []byte("room")},
+		{"extended", append(bytes.Clone(c), 0), []byte("room")},
+		{"not below N", priv.N.FillBytes(make([]byte, priv.Size())), []byte("room")},
+		{"zero", make([]byte, priv.Size()), []byte("room")},
+	}
+	for _, tt := range tests {
+		if _, err := rsa.DecryptOAEP(priv, tt.ciphertext, tt.label); !errors.Is(err, rsa.ErrDecryption) {
+			t.Errorf("%s: err = %v, want ErrDecryption", tt.name, err)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv, err := rsa.GenerateKey(1024, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std := stdKey(t, priv)
+	msg := []byte("signed message")
+
+	sig, err := rsa.Sign(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.Verify(&priv.PublicKey, msg, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// PKCS #1 v1.5 signatures are deterministic, so crypto/rsa must produce
+	// the same bytes
+	digest := sha256.Sum256(msg)
+	want, err := stdrsa.SignPKCS1v15(nil, std, stdcrypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, want) {
+		t.Errorf("signature differs from crypto/rsa")
+	}
+	if err := stdrsa.VerifyPKCS1v15(&std.PublicKey, stdcrypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("crypto/rsa rejects the signature: %v", err)
+	}
+
+	flipped := bytes.Clone(sig)
+	flipped[10] ^= 0x01
+	tests := []struct {
+		name string
+		msg  []byte
+		sig  []byte
+	}{
+		{"other message", []byte("signed messagf"), sig},
+		{"bit flip", msg, flipped},
+		{"truncated", msg, sig[1:]},
+		{"extended", msg, append(bytes.Clone(sig), 0)},
+		{"N", msg, priv.N.FillBytes(make([]byte, priv.Size()))},
+		{"zero", msg, make([]byte, priv.Size())},
+	}
+	for _, tt := range tests {
+		if err := rsa.Verify(&priv.PublicKey, tt.msg, tt.sig); !errors.Is(err, rsa.ErrVerification) {
+			t.Errorf("%s: err = %v, want ErrVerification", tt.name, err)
+		}
+	}
+}
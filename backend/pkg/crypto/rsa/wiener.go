@@ -0,0 +1,104 @@
+package rsa
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrAttackFailed is returned when no convergent of e/N yields the private key
+var ErrAttackFailed = errors.New("rsa: wiener attack failed, d is not small enough")
+
+// Convergent is one convergent k/d of the continued fraction of e/N
+type Convergent struct {
+	K *big.Int
+	D *big.Int
+}
+
+// WienerResult holds the outcome of WienerAttack
+type WienerResult struct {
+	D           *big.Int     // recovered private exponent, nil on failure
+	P, Q        *big.Int     // recovered factors of N, nil on failure
+	Convergents []Convergent // convergents tried, in order
+}
+
+// WienerAttack recovers the private exponent from a public key (e, N) when
+// d < N^(1/4)/3. Since e*d = 1 + k*phi(N) and phi(N) is close to N, k/d is
+// then a convergent of the continued fraction of e/N. Each candidate is
+// checked by solving for the factors of N.
+//
+// The result lists the convergents tried even when the attack fails.
+func WienerAttack(e, n *big.Int) (*WienerResult, error) {
+	if e.Sign() <= 0 || n.Sign() <= 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	result := &WienerResult{}
+	one := big.NewInt(1)
+
+	// Convergents h/k from the partial quotients a_i of e/N:
+	// h_i = a_i h_{i-1} + h_{i-2}, k_i = a_i k_{i-1} + k_{i-2}
+	hPrev, h := big.NewInt(0), big.NewInt(1)
+	kPrev, k := big.NewInt(1), big.NewInt(0)
+	num, den := new(big.Int).Set(e), new(big.Int).Set(n)
+	a, rem := new(big.Int), new(big.Int)
+
+	for den.Sign() != 0 {
+		a.QuoRem(num, den, rem)
+		num, den = den, new(big.Int).Set(rem)
+
+		hPrev, h = h, new(big.Int).Add(new(big.Int).Mul(a, h), hPrev)
+		kPrev, k = k, new(big.Int).Add(new(big.Int).Mul(a, k), kPrev)
+
+		c := Convergent{K: h, D: k}
+		result.Convergents = append(result.Convergents, c)
+		if c.K.Sign() == 0 {
+			continue
+		}
+
+		// phi = (e*d - 1) / k must be an integer
+		phi, r := new(big.Int).QuoRem(new(big.Int).Sub(new(big.Int).Mul(e, c.D), one), c.K, new(big.Int))
+		if r.Sign() != 0 {
+			continue
+		}
+
+		// p and q are the roots of x^2 - (N - phi + 1) x + N
+		if p, q := factorFromPhi(n, phi); p != nil {
+			result.D = new(big.Int).Set(c.D)
+			result.P, result.Q = p, q
+			return result, nil
+		}
+	}
+
+	return result, ErrAttackFailed
+}
+
+// factorFromPhi returns the factors of N = p*q given phi(N) = (p-1)(q-1),
+// or nil if phi does not match
+func factorFromPhi(n, phi *big.Int) (*big.Int, *big.Int) {
+	// s = p + q, disc = (p - q)^2 = s^2 - 4N
+	s := new(big.Int).Sub(n, phi)
+	s.Add(s, big.NewInt(1))
+	disc := new(big.Int).Mul(s, s)
+	disc.Sub(disc, new(big.Int).Lsh(n, 2))
+	if disc.Sign() < 0 {
+		return nil, nil
+	}
+
+	root := new(big.Int).Sqrt(disc)
+	if new(big.Int).Mul(root, root).Cmp(disc) != 0 {
+		return nil, nil
+	}
+
+	p := new(big.Int).Add(s, root)
+	q := new(big.Int).Sub(s, root)
+	if p.Bit(0) != 0 || q.Bit(0) != 0 {
+		return nil, nil
+	}
+	p.Rsh(p, 1)
+	q.Rsh(q, 1)
+
+	if q.Cmp(big.NewInt(1)) <= 0 || new(big.Int).Mul(p, q).Cmp(n) != 0 {
+		return nil, nil
+	}
+	return p, q
+}
@@ -0,0 +1,89 @@
+package rsa_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"CryptographyCW/pkg/crypto/rsa"
+)
+
+// weakKey builds a 1024-bit key whose private exponent is far below the
+// Wiener bound N^(1/4)/3, about 2^254
+func weakKey(t *testing.T) (e, n, d, p, q *big.Int) {
+	t.Helper()
+	one := big.NewInt(1)
+	for {
+		p, err := rand.Prime(rand.Reader, 512)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := rand.Prime(rand.Reader, 512)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The bound assumes q < p < 2q
+		if p.Cmp(q) < 0 {
+			p, q = q, p
+		}
+		if p.Cmp(new(big.Int).Lsh(q, 1)) >= 0 {
+			continue
+		}
+
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d, err := rand.Prime(rand.Reader, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := new(big.Int).ModInverse(d, phi)
+		if e == nil {
+			continue
+		}
+		return e, new(big.Int).Mul(p, q), d, p, q
+	}
+}
+
+func TestWienerAttack(t *testing.T) {
+	e, n, d, p, q := weakKey(t)
+
+	result, err := rsa.WienerAttack(e, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.D.Cmp(d) != 0 {
+		t.Errorf("recovered d = %v, want %v", result.D, d)
+	}
+	if result.P.Cmp(p) != 0 || result.Q.Cmp(q) != 0 {
+		t.Errorf("recovered p, q = %v, %v, want %v, %v", result.P, result.Q, p, q)
+	}
+	if len(result.Convergents) == 0 {
+		t.Error("no convergents recorded")
+	}
+}
+
+func TestWienerAttackGeneratedKey(t *testing.T) {
+	priv, err := rsa.GenerateKey(1024, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := rsa.WienerAttack(big.NewInt(int64(priv.E)), priv.N)
+	if !errors.Is(err, rsa.ErrAttackFailed) {
+		t.Fatalf("err = %v, want ErrAttackFailed", err)
+	}
+	if result.D != nil || result.P != nil || result.Q != nil {
+		t.Error("failed attack returned a key")
+	}
+	if len(result.Convergents) == 0 {
+		t.Error("no convergents recorded")
+	}
+}
+
+func TestWienerAttackErrors(t *testing.T) {
+	for _, args := range [][2]int64{{0, 35}, {-3, 35}, {3, 0}, {3, -35}} {
+		if _, err := rsa.WienerAttack(big.NewInt(args[0]), big.NewInt(args[1])); !errors.Is(err, rsa.ErrInvalidArgument) {
+			t.Errorf("WienerAttack(%d, %d): err = %v", args[0], args[1], err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// Constants for Serpent
+const (
+	// SerpentBlockSize is the Serpent block size in bytes
+	SerpentBlockSize = 16
+	// SerpentRounds is the number of rounds in Serpent
+	SerpentRounds = 32
+	// serpentPhi is the fractional part of the golden ratio used by the key schedule
+	serpentPhi = 0x9E3779B9
+)
+
+// Serpent S-boxes S0..S7 (from the Serpent specification)
+var serpentSBox = [8][16]byte{
+	{3, 8, 15, 1, 10, 6, 5, 11, 14, 13, 4, 2, 7, 0, 9, 12},
+	{15, 12, 2, 7, 9, 0, 5, 10, 1, 11, 14, 8, 6, 13, 3, 4},
+	{8, 6, 7, 9, 3, 12, 10, 15, 13, 1, 14, 4, 0, 11, 5, 2},
+	{0, 15, 11, 8, 12, 9, 6, 3, 13, 1, 2, 4, 10, 7, 5, 14},
+	{1, 15, 8, 3, 12, 0, 11, 6, 2, 5, 4, 10, 9, 14, 7, 13},
+	{15, 5, 2, 11, 4, 10, 9, 12, 0, 3, 14, 8, 13, 6, 7, 1},
+	{7, 2, 12, 5, 8, 4, 6, 11, 14, 9, 1, 15, 13, 3, 10, 0},
+	{1, 13, 15, 0, 14, 8, 2, 11, 7, 4, 12, 10, 9, 3, 5, 6},
+}
+
+// serpentInvSBox holds the inverse S-boxes, built from serpentSBox
+var serpentInvSBox [8][16]byte
+
+func init() {
+	for i, box := range serpentSBox {
+		for x, y := range box {
+			serpentInvSBox[i][y] = byte(x)
+		}
+	}
+
+	Register(Algorithm{
+		Name:      "Serpent",
+		BlockSize: SerpentBlockSize,
+		KeySizes:  KeySizes{Min: 16, Max: 32, Step: 8},
+		KeySize:   32,
+		New: func(key []byte, _ string) (Cipher, error) {
+			return NewSerpent(key)
+		},
+	})
+}
+
+// Serpent represents a Serpent cipher instance
+type Serpent struct {
+	blockModes
+	subkeys [SerpentRounds + 1][4]uint32 // Round keys K0..K32
+}
+
+// NewSerpent creates a new Serpent cipher instance
+func NewSerpent(key []byte) (*Serpent, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("serpent: key must be 16, 24, or 32 bytes")
+	}
+
+	s := &Serpent{}
+	s.blockModes = blockModes{s}
+	s.expandKey(key)
+	return s, nil
+}
+
+// BlockSize returns the cipher's block size in bytes
+func (s *Serpent) BlockSize() int {
+	return SerpentBlockSize
+}
+
+// expandKey computes the 33 round keys. Short keys are padded to 256 bits
+// with a single one bit followed by zeros.
+func (s *Serpent) expandKey(key []byte) {
+	var padded [32]byte
+	copy(padded[:], key)
+	if len(key) < len(padded) {
+		padded[len(key)] = 0x01
+	}
+
+	// w[0..7] hold the prekeys w-8..w-1
+	var w [8 + 4*(SerpentRounds+1)]uint32
+	for i := 0; i < 8; i++ {
+		w[i] = binary.LittleEndian.Uint32(padded[4*i:])
+	}
+	for i := 8; i < len(w); i++ {
+		x := w[i-8] ^ w[i-5] ^ w[i-3] ^ w[i-1] ^ serpentPhi ^ uint32(i-8)
+		w[i] = bits.RotateLeft32(x, 11)
+	}
+
+	// Round key i goes through S-box (3 - i) mod 8
+	for i := range s.subkeys {
+		k := w[8+4*i : 12+4*i]
+		x0, x1, x2, x3 := serpentSubstitute(&serpentSBox[(35-i)%8], k[0], k[1], k[2], k[3])
+		s.subkeys[i] = [4]uint32{x0, x1, x2, x3}
+	}
+}
+
+// serpentSubstitute applies a 4-bit S-box in bitslice mode: bit j of
+// x0..x3 forms the nibble that is substituted at position j
+func serpentSubstitute(box *[16]byte, x0, x1, x2, x3 uint32) (uint32, uint32, uint32, uint32) {
+	var y0, y1, y2, y3 uint32
+	for j := 0; j < 32; j++ {
+		n := (x0>>j)&1 | (x1>>j)&1<<1 | (x2>>j)&1<<2 | (x3>>j)&1<<3
+		v := uint32(box[n])
+		y0 |= (v & 1) << j
+		y1 |= (v >> 1 & 1) << j
+		y2 |= (v >> 2 & 1) << j
+		y3 |= (v >> 3 & 1) << j
+	}
+	return y0, y1, y2, y3
+}
+
+// serpentLinear is the linear transformation applied between rounds
+func serpentLinear(x0, x1, x2, x3 uint32) (uint32, uint32, uint32, uint32) {
+	x0 = bits.RotateLeft32(x0, 13)
+	x2 = bits.RotateLeft32(x2, 3)
+	x1 ^= x0 ^ x2
+	x3 ^= x2 ^ x0<<3
+	x1 = bits.RotateLeft32(x1, 1)
+	x3 = bits.RotateLeft32(x3, 7)
+	x0 ^= x1 ^ x3
+	x2 ^= x3 ^ x1<<7
+	x0 = bits.RotateLeft32(x0, 5)
+	x2 = bits.RotateLeft32(x2, 22)
+	return x0, x1, x2, x3
+}
+
+// serpentInvLinear undoes serpentLinear
+func serpentInvLinear(x0, x1, x2, x3 uint32) (uint32, uint32, uint32, uint32) {
+	x2 = bits.RotateLeft32(x2, -22)
+	x0 = bits.RotateLeft32(x0, -5)
+	x2 ^= x3 ^ x1<<7
+	x0 ^= x1 ^ x3
+	x3 = bits.RotateLeft32(x3, -7)
+	x1 = bits.RotateLeft32(x1, -1)
+	x3 ^= x2 ^ x0<<3
+	x1 ^= x0 ^ x2
+	x2 = bits.RotateLeft32(x2, -3)
+	x0 = bits.RotateLeft32(x0, -13)
+	return x0, x1, x2, x3
+}
+
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (s *Serpent) encryptBlock(dst, src []byte) {
+	x0 := binary.LittleEndian.Uint32(src[0:4])
+	x1 := binary.LittleEndian.Uint32(src[4:8])
+	x2 := binary.LittleEndian.Uint32(src[8:12])
+	x3 := binary.LittleEndian.Uint32(src[12:16])
+
+	for r := 0; r < SerpentRounds; r++ {
+		k := &s.subkeys[r]
+		x0, x1, x2, x3 = serpentSubstitute(&serpentSBox[r%8], x0^k[0], x1^k[1], x2^k[2], x3^k[3])
+		if r < SerpentRounds-1 {
+			x0, x1, x2, x3 = serpentLinear(x0, x1, x2, x3)
+		}
+	}
+
+	// The last round replaces the linear transformation with a key mixing
+	k := &s.subkeys[SerpentRounds]
+	binary.LittleEndian.PutUint32(dst[0:4], x0^k[0])
+	binary.LittleEndian.PutUint32(dst[4:8], x1^k[1])
+	binary.LittleEndian.PutUint32(dst[8:12], x2^k[2])
+	binary.LittleEndian.PutUint32(dst[12:16], x3^k[3])
+}
+
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (s *Serpent) decryptBlock(dst, src []byte) {
+	k := &s.subkeys[SerpentRounds]
+	x0 := binary.LittleEndian.Uint32(src[0:4]) ^ k[0]
+	x1 := binary.LittleEndian.Uint32(src[4:8]) ^ k[1]
+	x2 := binary.LittleEndian.Uint32(src[8:12]) ^ k[2]
+	x3 := binary.LittleEndian.Uint32(src[12:16]) ^ k[3]
+
+	for r := SerpentRounds - 1; r >= 0; r-- {
+		if r < SerpentRounds-1 {
+			x0, x1, x2, x3 = serpentInvLinear(x0, x1, x2, x3)
+		}
+		x0, x1, x2, x3 = serpentSubstitute(&serpentInvSBox[r%8], x0, x1, x2, x3)
+		k := &s.subkeys[r]
+		x0, x1, x2, x3 = x0^k[0], x1^k[1], x2^k[2], x3^k[3]
+	}
+
+	binary.LittleEndian.PutUint32(dst[0:4], x0)
+	binary.LittleEndian.PutUint32(dst[4:8], x1)
+	binary.LittleEndian.PutUint32(dst[8:12], x2)
+	binary.LittleEndian.PutUint32(dst[12:16], x3)
+}
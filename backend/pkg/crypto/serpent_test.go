@@ -0,0 +1,20 @@
+package crypto_test
+
+import (
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+func TestSerpentKnownAnswers(t *testing.T) {
+	checkVectors(t, "serpent.txt")
+}
+
+func TestSerpentKeySizes(t *testing.T) {
+	for n := 0; n <= 40; n++ {
+		_, err := crypto.NewSerpent(make([]byte, n))
+		if ok := n == 16 || n == 24 || n == 32; (err == nil) != ok {
+			t.Errorf("%d-byte key: err = %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package crypto
+
+import "io"
+
+// streamChunkSize is the amount of data buffered by the stream wrappers,
+// rounded down to a whole number of blocks by streamChunk
+const streamChunkSize = 64 * 1024
+
+// streamChunk returns the largest multiple of blockSize not above streamChunkSize
+func streamChunk(blockSize int) int {
+	return streamChunkSize - streamChunkSize%blockSize
+}
+
+// newModeStream creates an encrypting or decrypting stream for the named mode
+func newModeStream(c Cipher, mode string, iv []byte, decrypt bool) (blockStream, error) {
+	m, err := GetMode(c, mode)
+	if err != nil {
+		return nil, err
+	}
+	sm, ok := m.(streamMode)
+	if !ok {
+		return nil, ErrUnsupportedMode
+	}
+
+	if decrypt {
+		return sm.decrypter(iv)
+	}
+	return sm.encrypter(iv)
+}
+
+// encryptWriter encrypts data written to it and passes the ciphertext on to w
+type encryptWriter struct {
+	w       io.Writer
+	s       blockStream
+	bs      int
+	padding PaddingType
+	buf     []byte // pending plaintext
+	out     []byte // ciphertext scratch buffer
+	closed  bool
+}
+
+// NewEncryptWriter returns a writer that encrypts everything written to it
+// using the given mode and padding and writes the ciphertext to w.
+// The result is identical to EncryptWithMode over the whole input.
+// Padding is applied when the writer is closed; Close does not close w.
+func NewEncryptWriter(w io.Writer, c Cipher, mode string, padding PaddingType, iv []byte) (io.WriteCloser, error) {
+	if _, err := Pad(nil, c.BlockSize(), padding); err != nil {
+		return nil, err
+	}
+	s, err := newModeStream(c, mode, iv, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:       w,
+		s:       s,
+		bs:      c.BlockSize(),
+		padding: padding,
+		buf:     make([]byte, 0, streamChunk(c.BlockSize())),
+		out:     make([]byte, streamChunk(c.BlockSize())),
+	}, nil
+}
+
+// Write buffers p and encrypts every complete chunk
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, ErrStreamClosed
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+
+		if len(e.buf) == cap(e.buf) {
+			if err := e.flush(e.buf); err != nil {
+				return written, err
+			}
+			e.buf = e.buf[:0]
+		}
+	}
+	return written, nil
+}
+
+// Close pads and encrypts the remaining data
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	padded, err := Pad(e.buf, e.bs, e.padding)
+	if err != nil {
+		return err
+	}
+	return e.flush(padded)
+}
+
+func (e *encryptWriter) flush(data []byte) error {
+	out := e.out[:len(data)]
+	if err := e.s.crypt(out, data); err != nil {
+		return err
+	}
+	_, err := e.w.Write(out)
+	return err
+}
+
+// decryptReader decrypts ciphertext read from r
+type decryptReader struct {
+	r       io.Reader
+	s       blockStream
+	bs      int
+	padding PaddingType
+	in      []byte // ciphertext not yet decrypted
+	plain   []byte // plaintext scratch buffer
+	out     []byte // decrypted plaintext not yet returned
+	eof     bool
+	err     error
+}
+
+// NewDecryptReader returns a reader that decrypts ciphertext read from r
+// using the given mode and padding. The result is identical to
+// DecryptWithMode over the whole input. The last block is held back until
+// r reaches EOF so its padding can be removed.
+func NewDecryptReader(r io.Reader, c Cipher, mode string, padding PaddingType, iv []byte) (io.Reader, error) {
+	if _, err := Pad(nil, c.BlockSize(), padding); err != nil {
+		return nil, err
+	}
+	s, err := newModeStream(c, mode, iv, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:       r,
+		s:       s,
+		bs:      c.BlockSize(),
+		padding: padding,
+		in:      make([]byte, 0, streamChunk(c.BlockSize())+c.BlockSize()),
+		plain:   make([]byte, streamChunk(c.BlockSize())+c.BlockSize()),
+	}, nil
+}
+
+// Read returns decrypted plaintext
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.eof {
+			return 0, io.EOF
+		}
+		d.fill()
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// fill reads more ciphertext and decrypts what can safely be released
+func (d *decryptReader) fill() {
+	n, err := io.ReadFull(d.r, d.in[len(d.in):cap(d.in)])
+	d.in = d.in[:len(d.in)+n]
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		d.eof = true
+	default:
+		d.err = err
+		return
+	}
+
+	if d.eof {
+		if len(d.in)%d.bs != 0 {
+			d.err = ErrInvalidBlockSize
+			return
+		}
+		plain := d.plain[:len(d.in)]
+		if d.err = d.s.crypt(plain, d.in); d.err != nil {
+			return
+		}
+		d.out, d.err = Unpad(plain, d.bs, d.padding)
+		d.in = d.in[:0]
+		return
+	}
+
+	// Keep the last block back, it may hold the padding
+	k := (len(d.in)/d.bs - 1) * d.bs
+	if k <= 0 {
+		return
+	}
+	plain := d.plain[:k]
+	if d.err = d.s.crypt(plain, d.in[:k]); d.err != nil {
+		return
+	}
+	d.out = plain
+	d.in = d.in[:copy(d.in, d.in[k:])]
+}
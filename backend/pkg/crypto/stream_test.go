@@ -0,0 +1,136 @@
+package crypto_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+// streamChunk mirrors the stream wrappers' 64 KiB buffer
+const streamChunk = 64 * 1024
+
+// writeUneven writes msg to w in pieces of varying size
+func writeUneven(w io.Writer, msg []byte) error {
+	sizes := []int{1, 7, 1000, streamChunk, 3}
+	for i := 0; len(msg) > 0; i++ {
+		n := min(sizes[i%len(sizes)], len(msg))
+		if _, err := w.Write(msg[:n]); err != nil {
+			return err
+		}
+		msg = msg[n:]
+	}
+	return nil
+}
+
+func encryptStream(c crypto.Cipher, msg, iv []byte, mode string, padding crypto.PaddingType) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := crypto.NewEncryptWriter(&buf, c, mode, padding, iv)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeUneven(w, msg); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decryptStream(c crypto.Cipher, ct, iv []byte, mode string, padding crypto.PaddingType) ([]byte, error) {
+	r, err := crypto.NewDecryptReader(iotest.HalfReader(bytes.NewReader(ct)), c, mode, padding, iv)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStreamMatchesWithMode(t *testing.T) {
+	for _, mc := range modeCiphers(t) {
+		bs := mc.c.BlockSize()
+		iv := testMessage(bs)
+		for _, mode := range crypto.Modes {
+			for _, padding := range crypto.Paddings {
+				for _, n := range []int{0, 1, bs, streamChunk - 1, streamChunk, 2*streamChunk + bs + 3} {
+					msg := testMessage(n)
+					ct, err := encryptStream(mc.c, msg, iv, mode, padding)
+					if err != nil {
+						t.Fatalf("%s %s %v, %d bytes: %v", mc.name, mode, padding, n, err)
+					}
+
+					// ISO 10126 filler is random, so only the length and the
+					// decryption can be compared
+					want, err := mc.c.EncryptWithMode(msg, iv, mode, padding)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if padding == crypto.ISO10126 && len(ct) != len(want) || padding != crypto.ISO10126 && !bytes.Equal(ct, want) {
+						t.Errorf("%s %s %v, %d bytes: writer output differs from EncryptWithMode", mc.name, mode, padding, n)
+					}
+
+					pt, err := decryptStream(mc.c, ct, iv, mode, padding)
+					if err != nil {
+						t.Fatalf("%s %s %v, %d bytes: %v", mc.name, mode, padding, n, err)
+					}
+					if !bytes.Equal(pt, msg) {
+						t.Errorf("%s %s %v, %d bytes: reader output differs from the plaintext", mc.name, mode, padding, n)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestStreamTruncated(t *testing.T) {
+	for _, mc := range modeCiphers(t) {
+		bs := mc.c.BlockSize()
+		iv := testMessage(bs)
+		msg := testMessage(streamChunk + 5*bs + 3)
+		for _, mode := range crypto.Modes {
+			ct, err := mc.c.EncryptWithMode(msg, iv, mode, crypto.PKCS7)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := decryptStream(mc.c, ct[:len(ct)-1], iv, mode, crypto.PKCS7); !errors.Is(err, crypto.ErrInvalidBlockSize) {
+				t.Errorf("%s %s, one byte short: err = %v", mc.name, mode, err)
+			}
+			if _, err := decryptStream(mc.c, ct[:len(ct)-bs], iv, mode, crypto.PKCS7); !errors.Is(err, crypto.ErrInvalidPadding) {
+				t.Errorf("%s %s, one block short: err = %v", mc.name, mode, err)
+			}
+		}
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	c, err := crypto.NewTwoFish(testKey(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := testMessage(c.BlockSize())
+
+	if _, err := crypto.NewEncryptWriter(io.Discard, c, "XTS", crypto.PKCS7, iv); !errors.Is(err, crypto.ErrUnsupportedMode) {
+		t.Errorf("unknown mode: err = %v", err)
+	}
+	if _, err := crypto.NewDecryptReader(bytes.NewReader(nil), c, "CBC", "PKCS5", iv); !errors.Is(err, crypto.ErrUnsupportedPadding) {
+		t.Errorf("unknown padding: err = %v", err)
+	}
+	if _, err := crypto.NewEncryptWriter(io.Discard, c, "CBC", crypto.PKCS7, iv[:3]); !errors.Is(err, crypto.ErrInvalidIV) {
+		t.Errorf("short IV: err = %v", err)
+	}
+
+	w, err := crypto.NewEncryptWriter(io.Discard, c, "CBC", crypto.PKCS7, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("late")); !errors.Is(err, crypto.ErrStreamClosed) {
+		t.Errorf("write after Close: err = %v", err)
+	}
+}
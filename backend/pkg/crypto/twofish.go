@@ -13,7 +13,7 @@ const (
 	Rounds = 16
 )
 
-// Pre-computed RS matrix for MDS (from TwoFish specification)
+// Reed-Solomon matrix used to derive the S-box keys (from TwoFish specification)
 var RS = [4][8]byte{
 	{0x01, 0xA4, 0x55, 0x87, 0x5A, 0x58, 0xDB, 0x9E},
 	{0xA4, 0x56, 0x82, 0xF3, 0x1E, 0xC6, 0x68, 0xE5},
@@ -92,22 +92,35 @@ var q1 = [256]byte{
 	0x16, 0x25, 0x86, 0x56, 0x55, 0x09, 0xBE, 0x91,
 }
 
-// S-boxes
-var sBox [2][256]byte
+// MDS matrix used by the h and g functions (from TwoFish specification)
+var MDS = [4][4]byte{
+	{0x01, 0xEF, 0x5B, 0x5B},
+	{0x5B, 0xEF, 0xEF, 0x01},
+	{0xEF, 0x5B, 0x01, 0xEF},
+	{0xEF, 0x01, 0xEF, 0x5B},
+}
 
-// TwoFish represents a TwoFish cipher instance
-type TwoFish struct {
-	roundKeys [40]uint32 // Expanded key for rounds
-	sKeys     [4]uint32  // S-box keys
+// Primitive polynomials for GF(2^8) arithmetic
+const (
+	mdsPoly = 0x169 // x^8 + x^6 + x^5 + x^3 + 1
+	rsPoly  = 0x14D // x^8 + x^6 + x^3 + x^2 + 1
+)
+
+// qOrder lists which q permutation is applied to each byte of the h function
+// input, stage by stage from the outermost key word inwards.
+var qOrder = [5][4]*[256]byte{
+	{&q1, &q0, &q0, &q1}, // before XOR with L[3] (256-bit keys)
+	{&q1, &q1, &q0, &q0}, // before XOR with L[2] (192 and 256-bit keys)
+	{&q0, &q1, &q0, &q1}, // before XOR with L[1]
+	{&q0, &q0, &q1, &q1}, // before XOR with L[0]
+	{&q1, &q0, &q1, &q0}, // final permutation
 }
 
-// Initialize S-boxes using the proper q-permutations
-func init() {
-	for i := 0; i < 256; i++ {
-		// Apply q0 and q1 permutations as per TwoFish specification
-		sBox[0][i] = q0[i]
-		sBox[1][i] = q1[i]
-	}
+// TwoFish represents a TwoFish cipher instance
+type TwoFish struct {
+	blockModes
+	roundKeys [40]uint32     // Whitening and round subkeys K0..K39
+	sBoxes    [4][256]uint32 // Key-dependent S-boxes merged with the MDS columns
 }
 
 // Helper functions for bit rotation
@@ -119,6 +132,44 @@ func twofishRotateRight(x uint32, n int) uint32 {
 	return (x >> uint(n&31)) | (x << uint((32-n)&31))
 }
 
+// gfMult multiplies two elements of GF(2^8) modulo the given polynomial
+func gfMult(a, b byte, poly uint32) byte {
+	x, y := uint32(a), uint32(b)
+	result := uint32(0)
+	for y != 0 {
+		if y&1 != 0 {
+			result ^= x
+		}
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= poly
+		}
+		y >>= 1
+	}
+	return byte(result)
+}
+
+// mdsColumnMult multiplies byte b by column col of the MDS matrix
+func mdsColumnMult(b byte, col int) uint32 {
+	var result uint32
+	for row := 0; row < 4; row++ {
+		result |= uint32(gfMult(MDS[row][col], b, mdsPoly)) << (8 * row)
+	}
+	return result
+}
+
+func init() {
+	Register(Algorithm{
+		Name:      "TwoFish",
+		BlockSize: BlockSize,
+		KeySizes:  KeySizes{Min: 16, Max: 32, Step: 8},
+		KeySize:   32,
+		New: func(key []byte, _ string) (Cipher, error) {
+			return NewTwoFish(key)
+		},
+	})
+}
+
 // NewTwoFish creates a new TwoFish cipher instance
 func NewTwoFish(key []byte) (*TwoFish, error) {
 	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
@@ -126,302 +177,152 @@ func NewTwoFish(key []byte) (*TwoFish, error) {
 	}
 
 	t := &TwoFish{}
+	t.blockModes = blockModes{t}
 	t.expandKey(key)
 	return t, nil
 }
 
-// h function for key schedule
-func (t *TwoFish) h(x uint32, L []uint32, k int) uint32 {
-	b := [4]byte{
-		byte(x), byte(x >> 8),
-		byte(x >> 16), byte(x >> 24),
-	}
+// BlockSize returns the cipher's block size in bytes
+func (t *TwoFish) BlockSize() int {
+	return BlockSize
+}
 
-	if k == 4 {
-		b[0] = sBox[1][b[0]] ^ byte(L[3])
-		b[1] = sBox[0][b[1]] ^ byte(L[3]>>8)
-		b[2] = sBox[0][b[2]] ^ byte(L[3]>>16)
-		b[3] = sBox[1][b[3]] ^ byte(L[3]>>24)
+// permute runs byte x at position pos through the q permutations of the
+// h function, XORing in the key words of L between stages
+func permute(x byte, pos int, L []uint32) byte {
+	for stage := 4 - len(L); stage < 4; stage++ {
+		x = qOrder[stage][pos][x] ^ byte(L[3-stage]>>(8*pos))
 	}
-	if k >= 3 {
-		b[0] = sBox[1][b[0]] ^ byte(L[2])
-		b[1] = sBox[0][b[1]] ^ byte(L[2]>>8)
-		b[2] = sBox[0][b[2]] ^ byte(L[2]>>16)
-		b[3] = sBox[1][b[3]] ^ byte(L[2]>>24)
-	}
-	if k >= 2 {
-		b[0] = sBox[1][b[0]] ^ byte(L[1])
-		b[1] = sBox[0][b[1]] ^ byte(L[1]>>8)
-		b[2] = sBox[0][b[2]] ^ byte(L[1]>>16)
-		b[3] = sBox[1][b[3]] ^ byte(L[1]>>24)
-	}
-	b[0] = sBox[1][b[0]] ^ byte(L[0])
-	b[1] = sBox[0][b[1]] ^ byte(L[0]>>8)
-	b[2] = sBox[0][b[2]] ^ byte(L[0]>>16)
-	b[3] = sBox[1][b[3]] ^ byte(L[0]>>24)
+	return qOrder[4][pos][x]
+}
 
-	// MDS matrix multiplication
+// h function as defined in the specification
+func h(x uint32, L []uint32) uint32 {
 	result := uint32(0)
-	result ^= uint32(RS[0][b[0]]) << 24
-	result ^= uint32(RS[1][b[1]]) << 16
-	result ^= uint32(RS[2][b[2]]) << 8
-	result ^= uint32(RS[3][b[3]])
+	for pos := 0; pos < 4; pos++ {
+		result ^= mdsColumnMult(permute(byte(x>>(8*pos)), pos, L), pos)
+	}
+	return result
+}
 
+// rsMult multiplies eight key bytes by the RS matrix, producing one S-box key word
+func rsMult(key []byte) uint32 {
+	var result uint32
+	for row := 0; row < 4; row++ {
+		var b byte
+		for col := 0; col < 8; col++ {
+			b ^= gfMult(RS[row][col], key[col], rsPoly)
+		}
+		result |= uint32(b) << (8 * row)
+	}
 	return result
 }
 
 func (t *TwoFish) expandKey(key []byte) {
-	k := len(key) / 4
-	words := make([]uint32, k)
+	k := len(key) / 8
 
-	// Convert key bytes to words
+	// Split key into even (Me) and odd (Mo) little-endian words
+	even := make([]uint32, k)
+	odd := make([]uint32, k)
 	for i := 0; i < k; i++ {
-		words[i] = binary.BigEndian.Uint32(key[4*i:])
+		even[i] = binary.LittleEndian.Uint32(key[8*i:])
+		odd[i] = binary.LittleEndian.Uint32(key[8*i+4:])
 	}
 
-	// Generate round subkeys
+	// Generate whitening and round subkeys
+	const rho = 0x01010101
 	for i := 0; i < 20; i++ {
-		A := t.h(uint32(2*i), words, k)
-		B := twofishRotateLeft(t.h(uint32(2*i+1), words, k), 8)
+		A := h(uint32(2*i)*rho, even)
+		B := twofishRotateLeft(h(uint32(2*i+1)*rho, odd), 8)
 		t.roundKeys[2*i] = A + B
 		t.roundKeys[2*i+1] = twofishRotateLeft(A+2*B, 9)
 	}
 
-	// Generate S-box keys
+	// Generate S-box keys, in reverse order
+	sKeys := make([]uint32, k)
 	for i := 0; i < k; i++ {
-		t.sKeys[i] = t.h(words[k-1-i], words, k)
+		sKeys[k-1-i] = rsMult(key[8*i : 8*i+8])
 	}
-}
-
-// F function used in round function
-func (t *TwoFish) f(R uint32, round int) uint32 {
-	t0 := byte(R)
-	t1 := byte(R >> 8)
-	t2 := byte(R >> 16)
-	t3 := byte(R >> 24)
-
-	// Apply S-boxes
-	t0 = sBox[0][t0]
-	t1 = sBox[1][t1]
-	t2 = sBox[0][t2]
-	t3 = sBox[1][t3]
 
-	// MDS matrix multiplication
-	result := uint32(0)
-	result ^= uint32(RS[0][t0]) << 24
-	result ^= uint32(RS[1][t1]) << 16
-	result ^= uint32(RS[2][t2]) << 8
-	result ^= uint32(RS[3][t3])
-
-	return result
-}
-
-// Encrypt encrypts a single block
-func (t *TwoFish) Encrypt(block []byte) []byte {
-	if len(block) != BlockSize {
-		panic("twofish: input block must be 16 bytes")
+	// Precompute the key-dependent S-boxes
+	for pos := 0; pos < 4; pos++ {
+		for x := 0; x < 256; x++ {
+			t.sBoxes[pos][x] = mdsColumnMult(permute(byte(x), pos, sKeys), pos)
+		}
 	}
+}
 
-	// Split block into four 32-bit words
-	P0 := binary.BigEndian.Uint32(block[0:4])
-	P1 := binary.BigEndian.Uint32(block[4:8])
-	P2 := binary.BigEndian.Uint32(block[8:12])
-	P3 := binary.BigEndian.Uint32(block[12:16])
+// g function used in the round function
+func (t *TwoFish) g(X uint32) uint32 {
+	return t.sBoxes[0][byte(X)] ^
+		t.sBoxes[1][byte(X>>8)] ^
+		t.sBoxes[2][byte(X>>16)] ^
+		t.sBoxes[3][byte(X>>24)]
+}
 
-	// Initial whitening
-	P0 ^= t.roundKeys[0]
-	P1 ^= t.roundKeys[1]
-	P2 ^= t.roundKeys[2]
-	P3 ^= t.roundKeys[3]
+// encryptBlock encrypts one block from src into dst, which may overlap
+func (t *TwoFish) encryptBlock(dst, src []byte) {
+	// Split block into four 32-bit words with input whitening
+	R0 := binary.LittleEndian.Uint32(src[0:4]) ^ t.roundKeys[0]
+	R1 := binary.LittleEndian.Uint32(src[4:8]) ^ t.roundKeys[1]
+	R2 := binary.LittleEndian.Uint32(src[8:12]) ^ t.roundKeys[2]
+	R3 := binary.LittleEndian.Uint32(src[12:16]) ^ t.roundKeys[3]
 
 	// Main encryption rounds
-	for i := 0; i < Rounds; i++ {
-		t0 := t.f(P0, i)
-		t1 := t.f(twofishRotateLeft(P1, 8), i)
+	for r := 0; r < Rounds; r++ {
+		T0 := t.g(R0)
+		T1 := t.g(twofishRotateLeft(R1, 8))
 
-		P3 = twofishRotateRight(P3, 1)
-		P3 ^= t0 + t1 + t.roundKeys[4+2*i]
-		P2 = twofishRotateLeft(P2, 1)
-		P2 ^= t0 + 2*t1 + t.roundKeys[5+2*i]
+		// Pseudo-Hadamard transform and round keys
+		F0 := T0 + T1 + t.roundKeys[2*r+8]
+		F1 := T0 + 2*T1 + t.roundKeys[2*r+9]
+
+		R2 = twofishRotateRight(R2^F0, 1)
+		R3 = twofishRotateLeft(R3, 1) ^ F1
 
 		// Swap for next round
-		P0, P1, P2, P3 = P2, P3, P0, P1
+		R0, R1, R2, R3 = R2, R3, R0, R1
 	}
 
 	// Undo last swap
-	P0, P1, P2, P3 = P2, P3, P0, P1
+	R0, R1, R2, R3 = R2, R3, R0, R1
 
 	// Output whitening
-	P0 ^= t.roundKeys[36]
-	P1 ^= t.roundKeys[37]
-	P2 ^= t.roundKeys[38]
-	P3 ^= t.roundKeys[39]
-
-	// Convert back to bytes
-	result := make([]byte, BlockSize)
-	binary.BigEndian.PutUint32(result[0:4], P0)
-	binary.BigEndian.PutUint32(result[4:8], P1)
-	binary.BigEndian.PutUint32(result[8:12], P2)
-	binary.BigEndian.PutUint32(result[12:16], P3)
-
-	return result
+	binary.LittleEndian.PutUint32(dst[0:4], R0^t.roundKeys[4])
+	binary.LittleEndian.PutUint32(dst[4:8], R1^t.roundKeys[5])
+	binary.LittleEndian.PutUint32(dst[8:12], R2^t.roundKeys[6])
+	binary.LittleEndian.PutUint32(dst[12:16], R3^t.roundKeys[7])
 }
 
-// Decrypt decrypts a single block
-func (t *TwoFish) Decrypt(block []byte) []byte {
-	if len(block) != BlockSize {
-		panic("twofish: input block must be 16 bytes")
-	}
-
-	// Split block into four 32-bit words
-	C0 := binary.BigEndian.Uint32(block[0:4])
-	C1 := binary.BigEndian.Uint32(block[4:8])
-	C2 := binary.BigEndian.Uint32(block[8:12])
-	C3 := binary.BigEndian.Uint32(block[12:16])
-
-	// Undo output whitening
-	C0 ^= t.roundKeys[36]
-	C1 ^= t.roundKeys[37]
-	C2 ^= t.roundKeys[38]
-	C3 ^= t.roundKeys[39]
+// decryptBlock decrypts one block from src into dst, which may overlap
+func (t *TwoFish) decryptBlock(dst, src []byte) {
+	// Split block into four 32-bit words and undo output whitening
+	R0 := binary.LittleEndian.Uint32(src[0:4]) ^ t.roundKeys[4]
+	R1 := binary.LittleEndian.Uint32(src[4:8]) ^ t.roundKeys[5]
+	R2 := binary.LittleEndian.Uint32(src[8:12]) ^ t.roundKeys[6]
+	R3 := binary.LittleEndian.Uint32(src[12:16]) ^ t.roundKeys[7]
 
 	// Main decryption rounds
-	for i := Rounds - 1; i >= 0; i-- {
-		t0 := t.f(C0, i)
-		t1 := t.f(twofishRotateLeft(C1, 8), i)
+	for r := Rounds - 1; r >= 0; r-- {
+		T0 := t.g(R0)
+		T1 := t.g(twofishRotateLeft(R1, 8))
 
-		C3 = twofishRotateLeft(C3^(t0+t1+t.roundKeys[4+2*i]), 1)
-		C2 = twofishRotateRight(C2^(t0+2*t1+t.roundKeys[5+2*i]), 1)
+		F0 := T0 + T1 + t.roundKeys[2*r+8]
+		F1 := T0 + 2*T1 + t.roundKeys[2*r+9]
+
+		R2 = twofishRotateLeft(R2, 1) ^ F0
+		R3 = twofishRotateRight(R3^F1, 1)
 
 		// Swap for next round
-		C0, C1, C2, C3 = C2, C3, C0, C1
+		R0, R1, R2, R3 = R2, R3, R0, R1
 	}
 
 	// Undo last swap
-	C0, C1, C2, C3 = C2, C3, C0, C1
-
-	// Undo initial whitening
-	C0 ^= t.roundKeys[0]
-	C1 ^= t.roundKeys[1]
-	C2 ^= t.roundKeys[2]
-	C3 ^= t.roundKeys[3]
-
-	// Convert back to bytes
-	result := make([]byte, BlockSize)
-	binary.BigEndian.PutUint32(result[0:4], C0)
-	binary.BigEndian.PutUint32(result[4:8], C1)
-	binary.BigEndian.PutUint32(result[8:12], C2)
-	binary.BigEndian.PutUint32(result[12:16], C3)
-
-	return result
-}
-
-// EncryptCBC encrypts data using CBC mode
-func (t *TwoFish) EncryptCBC(data []byte, iv []byte) []byte {
-	if len(iv) != BlockSize {
-		panic("twofish: IV must be 16 bytes")
-	}
-
-	// Pad data to block size if necessary
-	padLen := BlockSize - (len(data) % BlockSize)
-	paddedData := make([]byte, len(data)+padLen)
-	copy(paddedData, data)
-	// PKCS7 padding
-	for i := len(data); i < len(paddedData); i++ {
-		paddedData[i] = byte(padLen)
-	}
-
-	// Initialize previous block with IV
-	prev := make([]byte, BlockSize)
-	copy(prev, iv)
-
-	// Process each block
-	ciphertext := make([]byte, len(paddedData))
-	for i := 0; i < len(paddedData); i += BlockSize {
-		// XOR with previous ciphertext block (or IV for first block)
-		block := make([]byte, BlockSize)
-		for j := 0; j < BlockSize; j++ {
-			block[j] = paddedData[i+j] ^ prev[j]
-		}
-
-		// Encrypt block
-		encrypted := t.Encrypt(block)
-
-		// Copy to output and save for next iteration
-		copy(ciphertext[i:], encrypted)
-		copy(prev, encrypted)
-	}
-
-	return ciphertext
-}
-
-// DecryptCBC decrypts data using CBC mode
-func (t *TwoFish) DecryptCBC(ciphertext []byte, iv []byte) []byte {
-	if len(iv) != BlockSize {
-		panic("twofish: IV must be 16 bytes")
-	}
-	if len(ciphertext)%BlockSize != 0 {
-		panic("twofish: ciphertext is not a multiple of block size")
-	}
-
-	// Initialize previous block with IV
-	prev := make([]byte, BlockSize)
-	copy(prev, iv)
-
-	// Process each block
-	plaintext := make([]byte, len(ciphertext))
-	for i := 0; i < len(ciphertext); i += BlockSize {
-		// Save current ciphertext block for next iteration
-		current := make([]byte, BlockSize)
-		copy(current, ciphertext[i:i+BlockSize])
-
-		// Decrypt block
-		decrypted := t.Decrypt(current)
-
-		// XOR with previous ciphertext block (or IV for first block)
-		for j := 0; j < BlockSize; j++ {
-			plaintext[i+j] = decrypted[j] ^ prev[j]
-		}
-
-		// Update previous block
-		copy(prev, current)
-	}
-
-	// Remove PKCS7 padding
-	padLen := int(plaintext[len(plaintext)-1])
-	if padLen > BlockSize || padLen < 1 {
-		panic("twofish: invalid padding")
-	}
-	return plaintext[:len(plaintext)-padLen]
-}
-
-// EncryptWithMode encrypts data using the specified mode and padding
-func (c *TwoFish) EncryptWithMode(data []byte, iv []byte, mode string, padding PaddingType) []byte {
-	// Get the mode implementation
-	modeImpl, err := GetMode(c, mode)
-	if err != nil {
-		return nil
-	}
-
-	// Add padding
-	blockSize := len(iv)
-	paddedData := Pad(data, blockSize, padding)
-
-	// Encrypt using the selected mode
-	return modeImpl.Encrypt(paddedData, iv)
-}
-
-// DecryptWithMode decrypts data using the specified mode and padding
-func (c *TwoFish) DecryptWithMode(ciphertext []byte, iv []byte, mode string, padding PaddingType) []byte {
-	// Get the mode implementation
-	modeImpl, err := GetMode(c, mode)
-	if err != nil {
-		return nil
-	}
-
-	// Decrypt using the selected mode
-	decrypted := modeImpl.Decrypt(ciphertext, iv)
+	R0, R1, R2, R3 = R2, R3, R0, R1
 
-	// Remove padding
-	return Unpad(decrypted, padding)
+	// Undo input whitening
+	binary.LittleEndian.PutUint32(dst[0:4], R0^t.roundKeys[0])
+	binary.LittleEndian.PutUint32(dst[4:8], R1^t.roundKeys[1])
+	binary.LittleEndian.PutUint32(dst[8:12], R2^t.roundKeys[2])
+	binary.LittleEndian.PutUint32(dst[12:16], R3^t.roundKeys[3])
 }
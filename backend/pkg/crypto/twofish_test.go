@@ -0,0 +1,48 @@
+package crypto_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"CryptographyCW/pkg/crypto"
+)
+
+func TestTwoFishKnownAnswers(t *testing.T) {
+	checkVectors(t, "twofish.txt")
+}
+
+// TestTwoFishIterated runs the 49-step chain from the Twofish paper, where
+// each plaintext is the previous ciphertext and each key is the previous
+// plaintext followed by the previous key
+func TestTwoFishIterated(t *testing.T) {
+	tests := []struct {
+		keySize int
+		want    string
+	}{
+		{16, "5D9D4EEFFA9151575524F115815A12E0"},
+		{24, "E75449212BEEF9F4A390BD860A640941"},
+		{32, "37FE26FF1CF66175F5DDF4C33B97A205"},
+	}
+
+	for _, tt := range tests {
+		key := make([]byte, tt.keySize)
+		pt := make([]byte, crypto.BlockSize)
+		var ct []byte
+		for i := 0; i < 49; i++ {
+			c, err := crypto.NewTwoFish(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ct, err = c.Encrypt(pt); err != nil {
+				t.Fatal(err)
+			}
+			key = append(append([]byte{}, pt...), key...)[:tt.keySize]
+			pt = ct
+		}
+
+		if got := strings.ToUpper(hex.EncodeToString(ct)); got != tt.want {
+			t.Errorf("%d-bit key: got %s, want %s", tt.keySize*8, got, tt.want)
+		}
+	}
+}
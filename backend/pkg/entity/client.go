@@ -1,7 +1,9 @@
 package entity
 
 import (
+	"CryptographyCW/pkg/crypto"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
@@ -87,7 +89,7 @@ func (c *Client) handleWrite() {
 
 			// Use WriteJSON with our sanitized struct
 			if err := c.ws.WriteJSON(msg); err != nil {
-				slog.Error("Write failed:", err)
+				slog.Error("Write failed:", "error", err)
 				return
 			}
 
@@ -158,6 +160,29 @@ func (c *Client) handleRead() {
 			msg.IV = nil
 		}
 
+		// Handshake messages carry a base64 public value in the clear and are
+		// relayed as is; the server never learns the session key
+		if msg.MsgType == "dh_public" {
+			if _, ok := msg.Content.(string); !ok {
+				slog.Warn("Dropping handshake message without a public value", "from", msg.From)
+				continue
+			}
+			msg.From = c.Username
+			msg.IV = nil
+		}
+
+		// Public keys are relayed in the clear too, once they are well formed
+		if msg.MsgType == "public_key" {
+			key, err := parsePublicKeyContent(msg.Content)
+			if err != nil {
+				slog.Warn("Dropping malformed public key", "from", msg.From, "error", err)
+				continue
+			}
+			msg.From = c.Username
+			msg.Content = key
+			msg.IV = nil
+		}
+
 		// Send to the client's channel
 		select {
 		case c.From <- msg:
@@ -167,3 +192,20 @@ func (c *Client) handleRead() {
 		}
 	}
 }
+
+// parsePublicKeyContent converts the decoded JSON content of a public_key
+// message into a PublicKeyContent, rejecting keys crypto.ParsePublicKey
+// would not accept
+func parsePublicKeyContent(content interface{}) (PublicKeyContent, error) {
+	var key PublicKeyContent
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return key, err
+	}
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return key, err
+	}
+
+	_, err = crypto.ParsePublicKey(key.Algorithm, key.Key)
+	return key, err
+}
@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// b64 encodes a number as it appears in the JSON of a public_key message
+func b64(n *big.Int) string {
+	return base64.StdEncoding.EncodeToString(n.Bytes())
+}
+
+func TestParsePublicKeyContent(t *testing.T) {
+	// Odd numbers of 256 and 512 bits pass the structural checks
+	p := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1))
+	n := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 511), big.NewInt(1))
+	small := big.NewInt(1000003)
+
+	elgamal := func(p, g, y *big.Int) string {
+		return fmt.Sprintf(`{"algorithm":"ElGamal","key":{"p":%q,"g":%q,"y":%q}}`, b64(p), b64(g), b64(y))
+	}
+	rabin := func(n *big.Int) string {
+		return fmt.Sprintf(`{"algorithm":"Rabin","key":{"n":%q}}`, b64(n))
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		ok      bool
+	}{
+		{"ElGamal", elgamal(p, big.NewInt(2), big.NewInt(3)), true},
+		{"Rabin", rabin(n), true},
+		{"ElGamal small p", elgamal(small, big.NewInt(2), big.NewInt(3)), false},
+		{"ElGamal g = 1", elgamal(p, big.NewInt(1), big.NewInt(3)), false},
+		{"ElGamal missing y", fmt.Sprintf(`{"algorithm":"ElGamal","key":{"p":%q,"g":"Ag=="}}`, b64(p)), false},
+		{"Rabin even n", rabin(new(big.Int).Sub(n, big.NewInt(1))), false},
+		{"Rabin small n", rabin(small), false},
+		{"unknown algorithm", fmt.Sprintf(`{"algorithm":"RSA","key":{"n":%q}}`, b64(n)), false},
+		{"no key", `{"algorithm":"Rabin"}`, false},
+		{"bad base64", `{"algorithm":"Rabin","key":{"n":"not base64!"}}`, false},
+		{"string", `"key"`, false},
+		{"null", `null`, false},
+	}
+
+	for _, tt := range tests {
+		// Decode the whole message as the read loop does, so the content
+		// arrives as generic JSON
+		var msg Message
+		raw := fmt.Sprintf(`{"from":"alice","message_type":"public_key","content":%s}`, tt.content)
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		key, err := parsePublicKeyContent(msg.Content)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+
+		// The relayed content must encode back to the same key
+		out, err := json.Marshal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var again PublicKeyContent
+		if err := json.Unmarshal(out, &again); err != nil || !reflect.DeepEqual(again, key) {
+			t.Errorf("%s: relayed content %s does not round trip", tt.name, out)
+		}
+	}
+}
@@ -5,8 +5,16 @@ import "time"
 type Message struct {
 	From     string      `json:"from"`
 	SentAt   time.Time   `json:"sent_at"`
-	MsgType  string      `json:"message_type"` // [text/file_start/file_chunk/file_end/client_connected/client_disconnected]
+	MsgType  string      `json:"message_type"` // [text/file_start/file_chunk/file_end/client_connected/client_disconnected/dh_public/public_key]
 	Filename string      `json:"filename"`     // for files only
-	Content  interface{} `json:"content"`      // string for system messages, []byte for encrypted content
+	Content  interface{} `json:"content"`      // string for system and dh_public messages, PublicKeyContent for public_key, []byte for encrypted content
 	IV       []byte      `json:"iv"`           // Initialization Vector for encryption
 }
+
+// PublicKeyContent is the content of a public_key message. The key is a set
+// of named big-endian integers, e.g. p, g and y for ElGamal or n for Rabin,
+// as returned by the Components method of the key.
+type PublicKeyContent struct {
+	Algorithm string            `json:"algorithm"` // ElGamal or Rabin
+	Key       map[string][]byte `json:"key"`
+}
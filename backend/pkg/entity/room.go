@@ -7,13 +7,10 @@ import (
 var RoomFull = errors.New("room is full")
 var RoomNotFound = errors.New("room not found")
 
+// EncryptionAlgorithm is a cipher name from the pkg/crypto registry,
+// optionally with a "/" parameter such as "RC5-64/16"
 type EncryptionAlgorithm string
 
-const (
-	RC5     EncryptionAlgorithm = "RC5"
-	TwoFish EncryptionAlgorithm = "TwoFish"
-)
-
 type Mode string
 
 const (
@@ -23,6 +20,8 @@ const (
 	CFB  Mode = "CFB"
 	OFB  Mode = "OFB"
 	CTR  Mode = "CTR"
+
+	RandomDelta Mode = "RandomDelta"
 )
 
 type Padding string
@@ -40,6 +39,8 @@ type Room struct {
 	Algo     EncryptionAlgorithm
 	Mode     Mode
 	Padding  Padding
+	Salt     []byte // per-room salt for deriving the encryption key from the password
+	AEAD     bool   // clients must encrypt-then-MAC every message
 	Client1  *Client
 	ToC1     chan Message
 	Client2  *Client
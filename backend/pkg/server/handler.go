@@ -1,10 +1,16 @@
 package server
 
 import (
+	"CryptographyCW/pkg/crypto"
 	"CryptographyCW/pkg/entity"
 	"CryptographyCW/pkg/service"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/websocket"
 )
@@ -30,6 +36,7 @@ func (h *ChatHandler) InitRoutes() http.Handler {
 	router.HandleFunc("/ws/{room_name}", h.JoinRoom)
 	router.HandleFunc("POST /add_room", withCORS(h.CreateRoomHandler))
 	router.HandleFunc("POST /delete_room", withCORS(h.DeleteRoomHandler))
+	router.HandleFunc("GET /algorithms", withCORS(h.AlgorithmsHandler))
 
 	return router
 }
@@ -53,6 +60,9 @@ func (h *ChatHandler) CreateRoomHandler(w http.ResponseWriter, r *http.Request)
 	algorithm := r.FormValue("algorithm")
 	mode := r.FormValue("mode")
 	padding := r.FormValue("padding")
+	rounds := r.FormValue("rounds")
+	modulus := r.FormValue("modulus")
+	aead := r.FormValue("aead")
 
 	if name == "" || password == "" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -61,39 +71,66 @@ func (h *ChatHandler) CreateRoomHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validate algorithm
-	if algorithm != string(entity.RC5) && algorithm != string(entity.TwoFish) {
+	// Validate algorithm against the cipher registry; parameters come in their own fields
+	alg, ok := crypto.LookupAlgorithm(algorithm)
+	if !ok || strings.Contains(algorithm, "/") {
 		w.WriteHeader(http.StatusBadRequest)
 		http.Error(w, "invalid encryption algorithm", http.StatusBadRequest)
 		slog.Warn("Handler.CreateRoomHandler invalid algorithm", "algorithm", algorithm)
 		return
 	}
 
-	if mode != string(entity.ECB) &&
-		mode != string(entity.CBC) &&
-		mode != string(entity.PCBC) &&
-		mode != string(entity.CFB) &&
-		mode != string(entity.OFB) &&
-		mode != string(entity.CTR) {
+	// RC5 and RC6 rounds are optional and travel as part of the algorithm name, e.g. "RC5-64/16"
+	if rounds != "" {
+		n, err := strconv.Atoi(rounds)
+		if alg.Parameter != "rounds" || err != nil || n < 0 || n > 255 {
+			w.WriteHeader(http.StatusBadRequest)
+			http.Error(w, "invalid number of rounds", http.StatusBadRequest)
+			slog.Warn("Handler.CreateRoomHandler invalid rounds", "algorithm", algorithm, "rounds", rounds)
+			return
+		}
+		algorithm = fmt.Sprintf("%s/%d", algorithm, n)
+	}
+
+	// The Rijndael GF(2^8) modulus is optional too, given in hex as crypto.ParseModulus reads it
+	if modulus != "" {
+		n, err := crypto.ParseModulus(modulus)
+		if alg.Parameter != "modulus" || err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			http.Error(w, "invalid modulus", http.StatusBadRequest)
+			slog.Warn("Handler.CreateRoomHandler invalid modulus", "algorithm", algorithm, "modulus", modulus)
+			return
+		}
+		algorithm = fmt.Sprintf("%s/%X", algorithm, n)
+	}
 
+	if !slices.Contains(crypto.Modes, mode) {
 		w.WriteHeader(http.StatusBadRequest)
 		http.Error(w, "invalid mode", http.StatusBadRequest)
 		slog.Warn("Handler.CreateRoomHandler invalid mode", "mode", mode)
 		return
 	}
 
-	if padding != string(entity.Zeros) &&
-		padding != string(entity.PKCS7) &&
-		padding != string(entity.ISO10126) &&
-		padding != string(entity.ANSIX923) {
-
+	if !slices.Contains(crypto.Paddings, crypto.PaddingType(padding)) {
 		w.WriteHeader(http.StatusBadRequest)
 		http.Error(w, "invalid padding", http.StatusBadRequest)
 		slog.Warn("Handler.CreateRoomHandler invalid padding", "padding", padding)
 		return
 	}
+	// Authenticated encryption is optional and off unless requested
+	requireAEAD := false
+	if aead != "" {
+		var err error
+		if requireAEAD, err = strconv.ParseBool(aead); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			http.Error(w, "invalid aead flag", http.StatusBadRequest)
+			slog.Warn("Handler.CreateRoomHandler invalid aead flag", "aead", aead)
+			return
+		}
+	}
+
 	// TODO: pass to kafka
-	err := h.s.CreateRoom(name, password, entity.EncryptionAlgorithm(algorithm), entity.Mode(mode), entity.Padding(padding))
+	err := h.s.CreateRoom(name, password, entity.EncryptionAlgorithm(algorithm), entity.Mode(mode), entity.Padding(padding), requireAEAD)
 	if err != nil {
 		slog.Warn("Handler.DeleteRoomHandler failed to delete",
 			"err", err,
@@ -104,6 +141,16 @@ func (h *ChatHandler) CreateRoomHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// AlgorithmsHandler lists the registered algorithms, modes and paddings
+// a room can be created with
+func (h *ChatHandler) AlgorithmsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(crypto.Supported())
+	if err != nil {
+		slog.Warn("Handler.AlgorithmsHandler failed to write response", "err", err)
+	}
+}
+
 func (h *ChatHandler) DeleteRoomHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.FormValue("name")
 	password := r.FormValue("password")
@@ -129,7 +176,7 @@ func (h *ChatHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 	// init connection
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		slog.Warn("Handler.WSHandler failed to upgrade:", err)
+		slog.Warn("Handler.WSHandler failed to upgrade:", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
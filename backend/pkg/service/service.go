@@ -1,8 +1,11 @@
 package service
 
 import (
+	"CryptographyCW/pkg/crypto"
 	"CryptographyCW/pkg/entity"
+	"encoding/base64"
 	"errors"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -23,7 +26,7 @@ var RoomNotFoundError = errors.New("room not found")
 var RoomFullError = errors.New("room is full")
 var RoomPasswordError = errors.New("room password is incorrect")
 
-func (s *Service) CreateRoom(name string, password string, algo entity.EncryptionAlgorithm, mode entity.Mode, padding entity.Padding) error {
+func (s *Service) CreateRoom(name string, password string, algo entity.EncryptionAlgorithm, mode entity.Mode, padding entity.Padding, aead bool) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -31,12 +34,19 @@ func (s *Service) CreateRoom(name string, password string, algo entity.Encryptio
 		return RoomExistsError
 	}
 
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return err
+	}
+
 	s.Rooms[name] = &entity.Room{
 		Name:     name,
 		Password: password,
 		Algo:     algo,
 		Mode:     mode,
 		Padding:  padding,
+		Salt:     salt,
+		AEAD:     aead,
 		ToC1:     make(chan entity.Message, 10),
 		ToC2:     make(chan entity.Message, 10),
 	}
@@ -85,6 +95,8 @@ func (s *Service) Connect(roomName, roomPassword string, newClient *entity.Clien
 				"algorithm": string(room.Algo),
 				"mode":      string(room.Mode),
 				"padding":   string(room.Padding),
+				"salt":      base64.StdEncoding.EncodeToString(room.Salt),
+				"aead":      strconv.FormatBool(room.AEAD),
 			},
 			SentAt: time.Now(),
 		}:
@@ -108,6 +120,8 @@ func (s *Service) Connect(roomName, roomPassword string, newClient *entity.Clien
 				"algorithm": string(room.Algo),
 				"mode":      string(room.Mode),
 				"padding":   string(room.Padding),
+				"salt":      base64.StdEncoding.EncodeToString(room.Salt),
+				"aead":      strconv.FormatBool(room.AEAD),
 			},
 			SentAt: time.Now(),
 		}:
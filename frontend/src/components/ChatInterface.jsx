@@ -1,5 +1,5 @@
 import { useState, useEffect, useRef } from 'react';
-import { encryptMessage, decryptMessage, generateIV } from '../encryption';
+import { encryptMessage, decryptMessage, decryptText, generateDHKey, deriveSessionKey, generateIV } from '../encryption';
 
 function ChatInterface({ roomName, username, password, algorithm, mode, padding, onLeaveRoom, setAlgorithm, setMode, setPadding }) {
     const [messages, setMessages] = useState([]);
@@ -8,6 +8,10 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
     const [isUploading, setIsUploading] = useState(false);
     const messagesEndRef = useRef(null);
     const socketInitialized = useRef(false);
+    const keyRef = useRef(null); // session key agreed with the other participant
+    const saltRef = useRef(null); // room salt, mixed into the session key
+    const dhPendingRef = useRef(false); // we sent our public value and await the peer's
+    const aeadRef = useRef(false); // room requires encrypt-then-MAC
     const CHUNK_SIZE = 1024 * 1024; // 1MB chunks
 
     // File reception state
@@ -102,7 +106,9 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
                         if (settings.algorithm) setAlgorithm(settings.algorithm);
                         if (settings.mode) setMode(settings.mode);
                         if (settings.padding) setPadding(settings.padding);
-                        
+                        aeadRef.current = settings.aead === 'true';
+                        saltRef.current = settings.salt;
+
                         addMessage({
                             from: 'System',
                             message_type: 'text',
@@ -111,17 +117,62 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
                         });
                         break;
 
+                    case 'client_connected': {
+                        // The other participant joined: start a fresh key exchange
+                        keyRef.current = null;
+                        dhPendingRef.current = true;
+                        const ourPublic = await generateDHKey();
+                        newSocket.send(JSON.stringify({
+                            from: username,
+                            message_type: 'dh_public',
+                            content: ourPublic,
+                            sent_at: new Date().toISOString()
+                        }));
+                        addMessage(data);
+                        break;
+                    }
+                    case 'client_disconnected':
+                        keyRef.current = null;
+                        dhPendingRef.current = false;
+                        addMessage(data);
+                        break;
+                    case 'dh_public': {
+                        // Answer with our own public value unless we started the exchange
+                        if (!dhPendingRef.current) {
+                            const ourPublic = await generateDHKey();
+                            newSocket.send(JSON.stringify({
+                                from: username,
+                                message_type: 'dh_public',
+                                content: ourPublic,
+                                sent_at: new Date().toISOString()
+                            }));
+                        }
+                        dhPendingRef.current = false;
+                        // The password binds the key to the room, so a relay that swaps
+                        // public values without knowing it cannot read the messages
+                        keyRef.current = await deriveSessionKey(algorithm, data.content, password, saltRef.current);
+                        addMessage({
+                            from: 'System',
+                            message_type: 'text',
+                            content: `Session key established with ${data.from}`,
+                            sent_at: data.sent_at
+                        });
+                        break;
+                    }
+                    case 'public_key':
+                        // ElGamal and Rabin keys are relayed for other clients; this one has no use for them
+                        break;
                     case 'text':
                         try {
-                            const decrypted = await decryptMessage(
+                            const decrypted = await decryptText(
                                 algorithm,
-                                password,
+                                keyRef.current,
                                 data.content,
                                 data.iv,
                                 mode,
-                                padding
+                                padding,
+                                aeadRef.current ? data : null
                             );
-                            console.log('Decrypted message:', decrypted);
                             addMessage({
                                 ...data,
                                 content: decrypted
@@ -166,19 +217,15 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
                             if (!data.iv) {
                                 throw new Error('Missing IV for file chunk');
                             }
-                            const decryptedBase64 = await decryptMessage(
+                            const chunkBytes = await decryptMessage(
                                 algorithm,
-                                password,
+                                keyRef.current,
                                 data.content,
                                 data.iv,
                                 mode,
-                                padding
+                                padding,
+                                aeadRef.current ? data : null
                             );
-                            const binaryStr = atob(decryptedBase64);
-                            const chunkBytes = new Uint8Array(binaryStr.length);
-                            for (let i = 0; i < binaryStr.length; i++) {
-                                chunkBytes[i] = binaryStr.charCodeAt(i);
-                            }
                             // Always use the ref for up-to-date state
                             const fileReception = fileReceptionsRef.current.get(data.filename);
                             if (fileReception) {
@@ -288,18 +335,35 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
     const handleSendMessage = async (e) => {
         e.preventDefault();
         if (messageInput.trim() && socket && socket.readyState === WebSocket.OPEN) {
+            if (!keyRef.current) {
+                addMessage({
+                    from: 'System',
+                    message_type: 'text',
+                    content: 'Waiting for the other participant to complete the key exchange',
+                    sent_at: new Date().toISOString()
+                });
+                return;
+            }
             try {
                 // Generate random IV
-                const iv = generateIV(algorithm);
+                const iv = await generateIV(algorithm);
+
+                // The header is authenticated together with the content in AEAD rooms
+                const header = {
+                    from: username,
+                    sent_at: new Date().toISOString(),
+                    message_type: "text"
+                };
 
                 // Encrypt the message
                 const encrypted = await encryptMessage(
                     algorithm,
-                    password,
+                    keyRef.current,
                     messageInput,
                     iv,
                     mode,
-                    padding
+                    padding,
+                    aeadRef.current ? header : null
                 );
 
                 // Convert IV to base64 for transmission
@@ -307,9 +371,7 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
 
                 // Create message with encrypted content
                 const messageData = {
-                    from: username,
-                    sent_at: new Date().toISOString(),
-                    message_type: "text",
+                    ...header,
                     content: encrypted,
                     iv: ivBase64
                 };
@@ -375,20 +437,19 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
         messagesEndRef.current?.scrollIntoView({ behavior: 'smooth' });
     }, [messages]);
 
-    // Utility: Safe Uint8Array to base64 for large arrays
-    function uint8ToBase64(uint8) {
-        let binary = '';
-        const chunkSize = 0x8000; // 32KB
-        for (let i = 0; i < uint8.length; i += chunkSize) {
-            binary += String.fromCharCode.apply(null, uint8.subarray(i, i + chunkSize));
-        }
-        return btoa(binary);
-    }
-
     // --- New File Upload Handler ---
     const handleFileSelect = async (event) => {
         const file = event.target.files[0];
         if (!file || !socket || socket.readyState !== WebSocket.OPEN) return;
+        if (!keyRef.current) {
+            addMessage({
+                from: 'System',
+                message_type: 'text',
+                content: 'Waiting for the other participant to complete the key exchange',
+                sent_at: new Date().toISOString()
+            });
+            return;
+        }
         setUploadingFileName(file.name);
         setUploadProgress(0);
         setIsUploading(true);
@@ -431,24 +492,26 @@ function ChatInterface({ roomName, username, password, algorithm, mode, padding,
                 console.log(`Uploading file: ${file.name}`);
                 for (let i = 0; i < fileData.length; i += CHUNK_SIZE) {
                     const chunk = fileData.slice(i, i + CHUNK_SIZE);
-                    // Use safe base64 encoding
-                    const chunkBase64 = uint8ToBase64(chunk);
                     // Generate IV for this chunk
-                    const iv = generateIV(algorithm);
+                    const iv = await generateIV(algorithm);
                     const ivBase64 = btoa(String.fromCharCode.apply(null, iv));
+                    const header = {
+                        from: username,
+                        sent_at: new Date().toISOString(),
+                        message_type: "file_chunk"
+                    };
                     // Encrypt chunk
                     const encryptedChunk = await encryptMessage(
                         algorithm,
-                        password,
-                        chunkBase64,
+                        keyRef.current,
+                        chunk,
                         iv,
                         mode,
-                        padding
+                        padding,
+                        aeadRef.current ? header : null
                     );
                     const chunkMessage = {
-                        from: username,
-                        sent_at: new Date().toISOString(),
-                        message_type: "file_chunk",
+                        ...header,
                         filename: file.name,
                         content: encryptedChunk,
                         iv: ivBase64
@@ -1,22 +1,52 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
+import { loadAlgorithms } from '../encryption';
+// Generated from the backend registry by `go generate ./cmd/cryptotool`
+import fallbackOptions from '../algorithms.json';
+
+const optionLabels = {
+    '3DES': 'Triple DES',
+    RandomDelta: 'Random Delta',
+    ANSIX923: 'ANSI X.923',
+};
+
+// Placeholders for the optional algorithm parameter fields
+const parameterHints = {
+    rounds: 'Rounds (optional)',
+    modulus: 'GF(2^8) modulus in hex, e.g. 11B (optional)',
+};
+
+const emptyForm = {
+    roomName: '',
+    password: '',
+    username: '',
+    algorithm: 'RC5', // Default to RC5
+    mode: 'CBC',     // Default to CBC
+    padding: 'PKCS7', // Default to PKCS7
+    rounds: '',       // RC5 and RC6 round count, empty for the default
+    modulus: '',      // Rijndael GF(2^8) modulus, empty for the default
+    aead: false       // Authenticate messages with encrypt-then-MAC
+};
 
 function RoomManagement({ onJoinRoom }) {
     const [activeTab, setActiveTab] = useState('join');
-    const [formData, setFormData] = useState({
-        roomName: '',
-        password: '',
-        username: '',
-        algorithm: 'RC5', // Default to RC5
-        mode: 'CBC',     // Default to CBC
-        padding: 'PKCS7' // Default to PKCS7
-    });
+    const [formData, setFormData] = useState(emptyForm);
     const [message, setMessage] = useState('');
+    const [options, setOptions] = useState(fallbackOptions);
+
+    useEffect(() => {
+        loadAlgorithms()
+            .then(setOptions)
+            .catch(error => console.warn('Failed to load algorithms, using defaults:', error));
+    }, []);
 
     const handleChange = (e) => {
-        const { name, value } = e.target;
-        setFormData(prev => ({ ...prev, [name]: value }));
+        const { name, value, type, checked } = e.target;
+        setFormData(prev => ({ ...prev, [name]: type === 'checkbox' ? checked : value }));
     };
 
+    // The selected algorithm's optional parameter, 'rounds' or 'modulus', if any
+    const parameter = options.algorithms.find(a => a.name === formData.algorithm)?.parameter;
+
     const handleCreateRoom = async (e) => {
         e.preventDefault();
         try {
@@ -25,7 +55,15 @@ function RoomManagement({ onJoinRoom }) {
                 headers: {
                     'Content-Type': 'application/x-www-form-urlencoded',
                 },
-                body: `room_name=${encodeURIComponent(formData.roomName)}&password=${encodeURIComponent(formData.password)}&algorithm=${encodeURIComponent(formData.algorithm)}&mode=${encodeURIComponent(formData.mode)}&padding=${encodeURIComponent(formData.padding)}`
+                body: new URLSearchParams({
+                    room_name: formData.roomName,
+                    password: formData.password,
+                    algorithm: formData.algorithm,
+                    mode: formData.mode,
+                    padding: formData.padding,
+                    aead: formData.aead,
+                    ...(parameter && formData[parameter] && { [parameter]: formData[parameter] }),
+                }).toString()
             });
 
             if (!response.ok) {
@@ -33,7 +71,7 @@ function RoomManagement({ onJoinRoom }) {
             }
 
             setMessage('Room created successfully!');
-            setFormData({ roomName: '', password: '', username: '', algorithm: 'RC5', mode: 'CBC', padding: 'PKCS7' });
+            setFormData(emptyForm);
         } catch (error) {
             setMessage(`Error: ${error.message}`);
         }
@@ -55,7 +93,7 @@ function RoomManagement({ onJoinRoom }) {
             }
 
             setMessage('Room deleted successfully!');
-            setFormData({ roomName: '', password: '', username: '', algorithm: 'RC5', mode: 'CBC', padding: 'PKCS7' });
+            setFormData(emptyForm);
         } catch (error) {
             setMessage(`Error: ${error.message}`);
         }
@@ -152,20 +190,28 @@ function RoomManagement({ onJoinRoom }) {
                             onChange={handleChange}
                             required
                         >
-                            <option value="RC5">RC5</option>
-                            <option value="TwoFish">TwoFish</option>
+                            {options.algorithms.map(({ name }) => (
+                                <option key={name} value={name}>{optionLabels[name] || name}</option>
+                            ))}
                         </select>
+                        {parameter && (
+                            <input
+                                type="text"
+                                name={parameter}
+                                value={formData[parameter]}
+                                onChange={handleChange}
+                                placeholder={parameterHints[parameter]}
+                            />
+                        )}
                         <select
                             name="mode"
                             value={formData.mode}
                             onChange={handleChange}
                             required
                         >
-                            <option value="CBC">CBC</option>
-                            <option value="PCBC">PCBC</option>
-                            <option value="CFB">CFB</option>
-                            <option value="OFB">OFB</option>
-                            <option value="CTR">CTR</option>
+                            {options.modes.map(name => (
+                                <option key={name} value={name}>{optionLabels[name] || name}</option>
+                            ))}
                         </select>
                         <select
                             name="padding"
@@ -173,11 +219,19 @@ function RoomManagement({ onJoinRoom }) {
                             onChange={handleChange}
                             required
                         >
-                            <option value="Zeros">Zeros</option>
-                            <option value="ANSI_X923">ANSI X.923</option>
-                            <option value="PKCS7">PKCS7</option>
-                            <option value="ISO10126">ISO10126</option>
+                            {options.paddings.map(name => (
+                                <option key={name} value={name}>{optionLabels[name] || name}</option>
+                            ))}
                         </select>
+                        <label>
+                            <input
+                                type="checkbox"
+                                name="aead"
+                                checked={formData.aead}
+                                onChange={handleChange}
+                            />
+                            Authenticate messages
+                        </label>
                     </div>
                     <button type="submit">Create Room</button>
                 </form>
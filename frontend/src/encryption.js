@@ -39,77 +39,125 @@ async function initWasm() {
     }
 }
 
-// Encrypt a message
-async function encryptMessage(algorithm, key, message, iv, mode = 'CBC', padding = 'PKCS7') {
+// Turn an error result into an Error carrying its code, e.g. 'unsupported_mode'
+function wasmError(result) {
+    const error = new Error(result.error);
+    error.code = result.code;
+    return error;
+}
+
+// Encode bytes as base64 for JSON messages, in slices to stay within the
+// argument limit of String.fromCharCode
+function bytesToBase64(bytes) {
+    let binary = '';
+    const sliceSize = 0x8000;
+    for (let i = 0; i < bytes.length; i += sliceSize) {
+        binary += String.fromCharCode.apply(null, bytes.subarray(i, i + sliceSize));
+    }
+    return btoa(binary);
+}
+
+// Decode base64 from a JSON message into bytes
+function base64ToBytes(base64) {
+    const binary = atob(base64);
+    const bytes = new Uint8Array(binary.length);
+    for (let i = 0; i < binary.length; i++) {
+        bytes[i] = binary.charCodeAt(i);
+    }
+    return bytes;
+}
+
+// The WASM module only takes Uint8Arrays; accept base64 strings too
+function toBytes(data) {
+    return typeof data === 'string' ? base64ToBytes(data) : data;
+}
+
+// Encrypt a message, given as text or as a Uint8Array, and return the
+// ciphertext in base64. When ad ({ from, sent_at, message_type }) is given the
+// message is sealed with encrypt-then-MAC and those fields are authenticated too.
+async function encryptMessage(algorithm, key, message, iv, mode = 'CBC', padding = 'PKCS7', ad = null) {
     await initWasm();
     
     try {
-        // Convert message to UTF-8
-        const encoder = new TextEncoder();
-        const messageBytes = encoder.encode(message);
-        
-        // If IV is a base64 string, convert it to Uint8Array
-        let ivArray;
-        if (typeof iv === 'string') {
-            const binaryString = atob(iv);
-            ivArray = new Uint8Array(binaryString.length);
-            for (let i = 0; i < binaryString.length; i++) {
-                ivArray[i] = binaryString.charCodeAt(i);
-            }
-        } else {
-            ivArray = iv;
-        }
-        
+        const messageBytes = typeof message === 'string' ? new TextEncoder().encode(message) : message;
+        const ivArray = toBytes(iv);
+
         // Call the WASM encryption function with mode and padding
-        const result = window.encryptMessage(
-            algorithm,
-            key,
-            messageBytes,
-            ivArray,
-            mode,
-            padding
-        );
+        const result = ad
+            ? window.sealMessage(
+                algorithm,
+                key,
+                messageBytes,
+                ivArray,
+                mode,
+                padding,
+                ad.from,
+                ad.sent_at,
+                ad.message_type
+            )
+            : window.encryptMessage(
+                algorithm,
+                key,
+                messageBytes,
+                ivArray,
+                mode,
+                padding
+            );
 
         if (!result) {
             throw new Error('Encryption failed: no result returned');
         }
 
         if (result.error) {
-            throw new Error(result.error);
+            throw wasmError(result);
         }
 
-        // Return the base64 string directly
-        return result.data;
+        return bytesToBase64(result.data);
     } catch (error) {
         console.error('Encryption failed:', error);
         throw error;
     }
 }
 
-// Decrypt a message
-async function decryptMessage(algorithm, key, encryptedData, iv, mode = 'CBC', padding = 'PKCS7') {
+// Decrypt a base64 ciphertext and return the plaintext as a Uint8Array. When
+// ad is given the tag is verified first and tampered messages are rejected.
+async function decryptMessage(algorithm, key, encryptedData, iv, mode = 'CBC', padding = 'PKCS7', ad = null) {
     await initWasm();
     
     try {
+        const encryptedBytes = toBytes(encryptedData);
+        const ivArray = toBytes(iv);
+
         // Call the WASM decryption function with mode and padding
-        const result = window.decryptMessage(
-            algorithm,
-            key,
-            encryptedData,
-            iv,
-            mode,
-            padding
-        );
+        const result = ad
+            ? window.openMessage(
+                algorithm,
+                key,
+                encryptedBytes,
+                ivArray,
+                mode,
+                padding,
+                ad.from,
+                ad.sent_at,
+                ad.message_type
+            )
+            : window.decryptMessage(
+                algorithm,
+                key,
+                encryptedBytes,
+                ivArray,
+                mode,
+                padding
+            );
 
         if (!result) {
             throw new Error('Decryption failed: no result returned');
         }
 
         if (result.error) {
-            throw new Error(result.error);
+            throw wasmError(result);
         }
 
-        // Use result.data instead of result.result
         if (result.data === undefined) {
             throw new Error('Decryption failed: no data in result');
         }
@@ -121,13 +169,69 @@ async function decryptMessage(algorithm, key, encryptedData, iv, mode = 'CBC', p
     }
 }
 
-// Generate a random IV
-function generateIV(algorithm) {
-    // RC5 uses 8-byte IV, TwoFish uses 16-byte IV
-    const ivLength = algorithm === 'RC5' ? 8 : 16;
-    const iv = new Uint8Array(ivLength);
+// Decrypt a text message
+async function decryptText(algorithm, key, encryptedData, iv, mode, padding, ad = null) {
+    const bytes = await decryptMessage(algorithm, key, encryptedData, iv, mode, padding, ad);
+    return new TextDecoder().decode(bytes);
+}
+
+// Start a Diffie-Hellman handshake, returning our base64 public value
+async function generateDHKey() {
+    await initWasm();
+
+    const result = window.generateDHKey();
+    if (!result) {
+        throw new Error('Key generation failed: no result returned');
+    }
+    if (result.error) {
+        throw new Error(result.error);
+    }
+    return result.data;
+}
+
+// Finish the handshake with the peer's public value and derive the session
+// key, which also depends on the room password
+async function deriveSessionKey(algorithm, peerPublic, password, salt) {
+    await initWasm();
+
+    const result = window.deriveSessionKey(algorithm, peerPublic, password, salt);
+    if (!result) {
+        throw new Error('Key exchange failed: no result returned');
+    }
+    if (result.error) {
+        throw new Error(result.error);
+    }
+    return result.data;
+}
+
+// Supported algorithms, modes and paddings, fetched from the server once
+let algorithmsRequest = null;
+
+function loadAlgorithms() {
+    if (!algorithmsRequest) {
+        algorithmsRequest = fetch('/algorithms')
+            .then(response => response.ok ? response.json() : Promise.reject(new Error(response.statusText)))
+            .catch(error => {
+                // Let the next call try again
+                algorithmsRequest = null;
+                throw error;
+            });
+    }
+    return algorithmsRequest;
+}
+
+// Generate a random IV of one block for the algorithm. The block size comes
+// from the server and does not depend on a "/" parameter such as RC5 rounds.
+async function generateIV(algorithm) {
+    const name = algorithm.split('/')[0];
+    const { algorithms } = await loadAlgorithms();
+    const info = algorithms.find(a => a.name === name);
+    if (!info) {
+        throw new Error(`Unknown algorithm: ${algorithm}`);
+    }
+    const iv = new Uint8Array(info.block_size);
     crypto.getRandomValues(iv);
     return iv;
 }
 
-export { initWasm, encryptMessage, decryptMessage, generateIV }; 
\ No newline at end of file
+export { initWasm, encryptMessage, decryptMessage, decryptText, generateDHKey, deriveSessionKey, generateIV, loadAlgorithms }; 
\ No newline at end of file
@@ -19,6 +19,10 @@ export default defineConfig({
       '/delete_room': {
         target: 'http://localhost:8080',
         changeOrigin: true
+      },
+      '/algorithms': {
+        target: 'http://localhost:8080',
+        changeOrigin: true
       }
     }
   }